@@ -1,8 +1,12 @@
 package flatfile
 
 import (
+	"bytes"
+	"math"
 	"reflect"
 	"strconv"
+	"strings"
+	"unicode"
 	"unicode/utf8"
 	"unsafe"
 
@@ -10,17 +14,112 @@ import (
 )
 
 //assignBasedOnKind performs assignment of fieldData to field based on kind
-func assignBasedOnKind(kind reflect.Kind, field reflect.Value, fieldData []byte, ffpTag *flatfileTag) error {
+func assignBasedOnKind(kind reflect.Kind, field reflect.Value, fieldData []byte, ffpTag *flatfileTag, o *unmarshalOptions) error {
 	var err error
 	err = nil
+	//encodings that do not share ASCII, such as EBCDIC, decode every text field up front so numbers, bools and times can be read
+	//The field is then assigned with no encoding so it is not decoded twice
+	if o.encoding != nil && !o.encoding.ascii && isTextField(field.Type(), ffpTag) {
+		fieldData = o.encoding.decode(fieldData)
+		decodedOpts := *o
+		decodedOpts.encoding = nil
+		o = &decodedOpts
+	}
+	//the value ends at the first terminator, anything after it is filler
+	if ffpTag.termChk && !isRepeating(kind, ffpTag) {
+		if idx := bytes.IndexByte(fieldData, ffpTag.term); idx >= 0 {
+			fieldData = fieldData[:idx]
+		}
+	}
+	//padding characters are removed before any other handling so a masked field can be recognised
+	if ffpTag.trimSet != "" && !isRepeating(kind, ffpTag) {
+		fieldData = bytes.Trim(fieldData, ffpTag.trimSet)
+		if len(fieldData) == 0 && ffpTag.masked {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+	}
+	//a regex narrows the field to its first capture group before it is assigned
+	if ffpTag.regex != nil && !isRepeating(kind, ffpTag) {
+		match := ffpTag.regex.FindSubmatch(bytes.TrimSpace(fieldData))
+		switch {
+		case match == nil && ffpTag.regexZero:
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		case match == nil:
+			return errors.Errorf("flatfile.assignBasedOnKind: AssignmentError: %q does not match regex %s", fieldData, ffpTag.regex)
+		case len(match) > 1:
+			fieldData = match[1]
+		default:
+			fieldData = match[0]
+		}
+	}
+	//a converter named in the tag takes precedence over the field type, repeating fields convert each element
+	if ffpTag.conv != "" && !isRepeating(kind, ffpTag) {
+		return errors.Wrap(assignConverted(field, o.userData(fieldData), ffpTag.conv), "flatfile.assignBasedOnKind: AssignmentError")
+	}
+	//packed decimal is unpacked to decimal text then assigned as any other number
+	if ffpTag.packed && kind != reflect.Ptr && !isRepeating(kind, ffpTag) {
+		if fieldData, err = unpackDecimal(fieldData, ffpTag.packedScale); err != nil {
+			return errors.Wrap(err, "flatfile.assignBasedOnKind: AssignmentError")
+		}
+	}
+	fieldType := field.Type()
+	//predeclared types such as int and string have no methods and are never a database/sql null type, skipping the lookups
+	predeclared := fieldType.PkgPath() == "" && fieldType.Name() != ""
+	//a callback field is handed the data to do with as it pleases
+	if fieldType == fieldCallbackType {
+		return errors.Wrap(callField(field, o.userData(fieldData)), "flatfile.assignBasedOnKind: AssignmentError")
+	}
+	//types that unmarshal themselves take precedence over their kind
+	if !predeclared && field.CanAddr() && implementsFieldUnmarshaler(fieldType) {
+		return errors.Wrap(field.Addr().Interface().(FieldUnmarshaler).UnmarshalFlatfileField(o.userData(fieldData)), "flatfile.assignBasedOnKind: AssignmentError")
+	}
+	//registered enum types are translated from their code before the default kind handling
+	if codes, ok := lookupEnum(fieldType); ok {
+		return errors.Wrap(assignEnum(field, fieldData, codes), "flatfile.assignBasedOnKind: AssignmentError")
+	}
+	//time and database/sql null types are assigned as a single value rather than as a nested struct
+	if fieldType == timeType {
+		return errors.Wrap(assignTime(field, fieldData, ffpTag), "flatfile.assignBasedOnKind: AssignmentError")
+	}
+	if !predeclared && isSQLNullType(fieldType) {
+		return errors.Wrap(assignSQLNull(field, fieldData, ffpTag, o), "flatfile.assignBasedOnKind: AssignmentError")
+	}
+	if ffpTag.bitFlags && kind != reflect.Ptr && !isRepeating(kind, ffpTag) {
+		return errors.Wrap(assignBitFlags(field, fieldData), "flatfile.assignBasedOnKind: AssignmentError")
+	}
+	if ffpTag.zoned && kind != reflect.Ptr && !isRepeating(kind, ffpTag) {
+		return errors.Wrap(assignZoned(field, fieldData), "flatfile.assignBasedOnKind: AssignmentError")
+	}
+	if ffpTag.runLenChk && kind != reflect.Ptr && !isRepeating(kind, ffpTag) {
+		return errors.Wrap(assignRunLength(field, fieldData, ffpTag.runLen), "flatfile.assignBasedOnKind: AssignmentError")
+	}
+	if ffpTag.paren && ffpTag.override != "rune" {
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if fieldData, err = stripParens(fieldData); err != nil {
+				return errors.Wrap(err, "flatfile.assignBasedOnKind: AssignmentError")
+			}
+		}
+	}
+	if o.strictNumeric && ffpTag.override == "" && !ffpTag.money {
+		if fieldData, err = checkNumeric(kind, fieldData); err != nil {
+			return errors.Wrap(err, "flatfile.assignBasedOnKind: AssignmentError")
+		}
+	}
+	//numbers ignore surrounding whitespace of any kind, such as tabs and non-breaking spaces
+	if ffpTag.override == "" && isNumericKind(kind) {
+		fieldData = bytes.TrimSpace(fieldData)
+	}
 	switch kind {
 	case reflect.Bool:
-		err = assignBool(kind, field, fieldData)
+		err = assignBool(kind, field, fieldData, ffpTag, o)
 	case reflect.Uint:
 		err = assignUint(kind, field, fieldData)
 	case reflect.Uint8:
 		//check ffpTag.override == byte, meaning user wants to store the byte value itself
-		if ffpTag.override == "byte" {
+		if ffpTag.override == "byte" && len(fieldData) > 0 {
 			err = assignByte(field, fieldData[0])
 		} else {
 			err = assignUint8(kind, field, fieldData)
@@ -47,50 +146,537 @@ func assignBasedOnKind(kind reflect.Kind, field reflect.Value, fieldData []byte,
 		}
 	case reflect.Int64:
 		err = assignInt64(kind, field, fieldData)
-	case reflect.Float32:
-		err = assignFloat32(kind, field, fieldData)
-	case reflect.Float64:
-		err = assignFloat64(kind, field, fieldData)
+	case reflect.Float32, reflect.Float64:
+		if ffpTag.percentChk {
+			err = assignPercent(field, fieldData, ffpTag)
+			break
+		}
+		if ffpTag.money {
+			fieldData, err = stripMoney(fieldData)
+			if err != nil {
+				break
+			}
+		}
+		if kind == reflect.Float32 {
+			err = assignFloat32(kind, field, fieldData)
+		} else {
+			err = assignFloat64(kind, field, fieldData)
+		}
 	case reflect.String:
-		field.Set(reflect.ValueOf(string(fieldData)))
+		if ffpTag.lenPrefix {
+			fieldData, err = splitLengthPrefix(fieldData, ffpTag.length)
+			if err != nil {
+				break
+			}
+		}
+		if o.encoding != nil && !ffpTag.keepRaw {
+			fieldData = o.encoding.decode(fieldData)
+		}
+		if o.normalize != nil && !ffpTag.keepRaw {
+			fieldData = []byte(o.normalize(string(fieldData)))
+		}
+		if ffpTag.trimLeft {
+			fieldData = bytes.TrimLeftFunc(fieldData, unicode.IsSpace)
+		}
+		if ffpTag.trimRight {
+			fieldData = bytes.TrimRightFunc(fieldData, unicode.IsSpace)
+		}
+		field.SetString(string(fieldData))
 	case reflect.Struct:
-		err = Unmarshal(fieldData, field.Addr().Interface(), 0, 0, false)
+		err = unmarshal(fieldData, field.Addr().Interface(), 0, 0, false, o)
 	case reflect.Ptr:
+		//a blank number leaves an optional pointer nil rather than failing to parse
+		if isBlankNumber(field.Type().Elem(), fieldData, ffpTag) {
+			field.Set(reflect.Zero(field.Type()))
+			break
+		}
+		//allocate nil pointers so the pointed to struct, slice, array or primitive can be assigned
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
 		//If pointer to struct
-		if field.Elem().Kind() == reflect.Struct {
+		if isNestedStruct(field.Type().Elem()) {
 			//Unmarshal struct
-			err = Unmarshal(fieldData, field.Interface(), 0, 0, false)
+			err = unmarshal(fieldData, field.Interface(), 0, 0, false, o)
 		} else {
-			err = assignBasedOnKind(field.Elem().Kind(), field.Elem(), fieldData, ffpTag)
+			err = assignBasedOnKind(field.Elem().Kind(), field.Elem(), fieldData, ffpTag, o)
 		}
 	case reflect.Array:
+		if isRuneSequence(field.Type(), ffpTag) {
+			err = assignRunes(field, fieldData)
+			break
+		}
+		elemWidth := elementWidth(field.Type().Elem(), ffpTag)
 		for i := 0; i < field.Len(); i++ {
 			//fmt.Println("sl element interface", field.Index(i))
-			lowerBound := i * ffpTag.length
-			upperBound := lowerBound + ffpTag.length
-			assignBasedOnKind(field.Type().Elem().Kind(), field.Index(i), fieldData[lowerBound:upperBound], ffpTag)
+			lowerBound := i * elemWidth
+			upperBound := lowerBound + elemWidth
+			if upperBound > len(fieldData) {
+				err = errors.Errorf("flatfile.assignBasedOnKind: Element %d ends after the %d bytes of field data", i, len(fieldData))
+				break
+			}
+			err = assignBasedOnKind(field.Type().Elem().Kind(), field.Index(i), fieldData[lowerBound:upperBound], elementTag(field.Type().Elem(), ffpTag, lowerBound), o)
+			if err != nil {
+				err = wrapElementError(err, i, ffpTag, lowerBound)
+				break
+			}
 		}
 	case reflect.Slice:
-		if ffpTag.occurs < 1 {
+		//a length prefixed []byte holds a copy of the value so it does not keep the record alive
+		if ffpTag.lenPrefix {
+			var value []byte
+			if value, err = splitLengthPrefix(fieldData, ffpTag.length); err == nil {
+				field.SetBytes(append([]byte{}, value...))
+			}
+			break
+		}
+		if ffpTag.occurs == 0 && isRuneSequence(field.Type(), ffpTag) {
+			err = assignRunes(field, fieldData)
+			break
+		}
+		if ffpTag.occurs == 0 {
 			err = errors.Errorf("flatfile.assignBasedOnKind: Occurs clause must be provided when using slice. `flatfile:\"col,len,occurs\"`")
+			break
+		}
+		elemWidth := elementWidth(field.Type().Elem(), ffpTag)
+		if elemWidth == 0 {
+			err = errors.Errorf("flatfile.assignBasedOnKind: Occurs clause must be in the form rows x columns when using a slice of slices. `flatfile:\"col,len,3x4\"`")
+			break
 		}
-		//make slice of length ffpTag.occurs to avoid index out of range err
-		field.Set(reflect.MakeSlice(field.Type(), ffpTag.occurs, ffpTag.occurs))
-		for i := 0; i < ffpTag.occurs; i++ {
+		occurs := ffpTag.occurs
+		if occurs == greedyOccurs {
+			//size the slice from the whole occurrences left in the data
+			occurs = len(fieldData) / elemWidth
+		}
+		//make slice of length occurs to avoid index out of range err
+		field.Set(reflect.MakeSlice(field.Type(), occurs, occurs))
+		for i := 0; i < occurs; i++ {
 			//fmt.Println("sl element interface", field.Index(i))
-			lowerBound := i * ffpTag.length
-			upperBound := lowerBound + ffpTag.length
-			assignBasedOnKind(field.Type().Elem().Kind(), field.Index(i), fieldData[lowerBound:upperBound], ffpTag)
+			lowerBound := i * elemWidth
+			upperBound := lowerBound + elemWidth
+			if upperBound > len(fieldData) {
+				err = errors.Errorf("flatfile.assignBasedOnKind: Element %d ends after the %d bytes of field data", i, len(fieldData))
+				break
+			}
+			err = assignBasedOnKind(field.Type().Elem().Kind(), field.Index(i), fieldData[lowerBound:upperBound], elementTag(field.Type().Elem(), ffpTag, lowerBound), o)
+			if err != nil {
+				err = wrapElementError(err, i, ffpTag, lowerBound)
+				break
+			}
+		}
+	default:
+		//kinds such as chan, func and map are left unassigned unless WithStrictKinds is given
+		if o.strictKinds {
+			err = errors.Errorf("flatfile.assignBasedOnKind: Unsupported kind %s of field type %s", kind, field.Type())
 		}
 	}
 	return errors.Wrap(err, "flatfile.assignBasedOnKind: AssignmentError")
 }
 
-func assignBool(kind reflect.Kind, field reflect.Value, fieldData []byte) error {
-	newFieldVal, err := strconv.ParseBool(string(fieldData))
-	//fmt.Println(newFieldVal)
+//checkNumeric trims whitespace from the data of a numeric kind and checks it holds only digits after an optional leading sign
+//Float kinds may also have one decimal point. Data of other kinds is returned unchanged
+func checkNumeric(kind reflect.Kind, fieldData []byte) ([]byte, error) {
+	if !isNumericKind(kind) {
+		return fieldData, nil
+	}
+	isFloat := kind == reflect.Float32 || kind == reflect.Float64
+	number := bytes.TrimSpace(fieldData)
+	digits := number
+	if len(digits) > 0 && (digits[0] == '-' || digits[0] == '+') {
+		digits = digits[1:]
+	}
+	if isFloat {
+		digits = bytes.Replace(digits, []byte("."), nil, 1)
+	}
+	if len(digits) == 0 || bytes.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return nil, errors.Errorf("flatfile.checkNumeric: Non-numeric data %q", fieldData)
+	}
+	return number, nil
+}
+
+//fieldCallbackType is the type of a field that is called with its data instead of being assigned
+var fieldCallbackType = reflect.TypeOf((func([]byte) error)(nil))
+
+//callField calls the func([]byte) error held by field with fieldData, returning its error
+//The callback must be set before Unmarshal. fieldData is part of the data passed to Unmarshal so it must be copied to be kept
+func callField(field reflect.Value, fieldData []byte) error {
+	if field.IsNil() {
+		return errors.New("flatfile.callField: Callback field is nil. Set it before calling Unmarshal")
+	}
+	return field.Interface().(func([]byte) error)(fieldData)
+}
+
+//isBlankNumber returns true when fieldData is only whitespace and t is a numeric type, including named types such as type Code int
+//Fields read as raw bytes are never blank as a space is a valid value
+func isBlankNumber(t reflect.Type, fieldData []byte, ffpTag *flatfileTag) bool {
+	if ffpTag.bitFlags || ffpTag.zoned || ffpTag.packed || ffpTag.runLenChk || ffpTag.override != "" || len(bytes.TrimSpace(fieldData)) > 0 {
+		return false
+	}
+	return isNumericKind(t.Kind())
+}
+
+//isNumericKind returns true for the integer and float kinds
+func isNumericKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+//stripMoney removes the formatting of an accounting style amount e.g. "$1,234.56" or "(1,234.56)" leaving a parseable number
+//Surrounding whitespace, a currency symbol before the digits and grouping commas are removed. Parentheses make the amount negative
+func stripMoney(fieldData []byte) ([]byte, error) {
+	amount := strings.TrimSpace(string(fieldData))
+	negative := false
+	if strings.HasPrefix(amount, "(") {
+		if !strings.HasSuffix(amount, ")") {
+			return nil, errors.Errorf("flatfile.stripMoney: Unbalanced parentheses in amount %q", amount)
+		}
+		negative = true
+		amount = strings.TrimSpace(amount[1 : len(amount)-1])
+	}
+	if strings.HasPrefix(amount, "-") {
+		negative = !negative
+		amount = amount[1:]
+	}
+	amount = strings.TrimLeftFunc(amount, func(r rune) bool { return unicode.Is(unicode.Sc, r) || unicode.IsSpace(r) })
+	amount = strings.Replace(amount, ",", "", -1)
+	if negative {
+		amount = "-" + amount
+	}
+	return []byte(amount), nil
+}
+
+//stripParens rewrites an accounting style negative integer in parentheses e.g. (123) as -123 and removes surrounding whitespace
+//Empty parentheses are zero
+func stripParens(fieldData []byte) ([]byte, error) {
+	number := bytes.TrimSpace(fieldData)
+	if !bytes.HasPrefix(number, []byte("(")) {
+		return number, nil
+	}
+	if !bytes.HasSuffix(number, []byte(")")) {
+		return nil, errors.Errorf("flatfile.stripParens: Unbalanced parentheses in number %q", number)
+	}
+	number = bytes.TrimSpace(number[1 : len(number)-1])
+	if len(number) == 0 {
+		return []byte("0"), nil
+	}
+	return append([]byte("-"), number...), nil
+}
+
+//assignBitFlags assigns the raw bytes of fieldData to an unsigned field as a big-endian bitmask
+//A single byte status field e.g. `flatfile:"1,1,,bitflags"` holds up to 8 flags tested with field&mask
+func assignBitFlags(field reflect.Value, fieldData []byte) error {
+	switch field.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return errors.Errorf("flatfile.assignBitFlags: bitflags can only be used with an unsigned integer field not %s", field.Type())
+	}
+	if len(fieldData) > int(field.Type().Size()) {
+		return errors.Errorf("flatfile.assignBitFlags: %d bytes do not fit in %s", len(fieldData), field.Type())
+	}
+	var flags uint64
+	for _, b := range fieldData {
+		flags = flags<<8 | uint64(b)
+	}
+	field.SetUint(flags)
+	return nil
+}
+
+//assignZoned assigns EBCDIC zoned decimal data to an integer field e.g. F1 F2 D3 is -123
+//Each byte holds a digit in its low nibble, the zone of the last byte is C or F for positive and D for negative
+//The bytes are read raw so a zoned field is unaffected by WithEncoding
+func assignZoned(field reflect.Value, fieldData []byte) error {
+	if len(fieldData) == 0 {
+		return errors.New("flatfile.assignZoned: Zoned decimal field is empty")
+	}
+	var value int64
+	negative := false
+	for i, b := range fieldData {
+		zone, digit := b>>4, b&0x0F
+		if digit > 9 {
+			return errors.Errorf("flatfile.assignZoned: Invalid digit 0x%02X at byte %d", b, i+1)
+		}
+		switch {
+		case zone == 0xF:
+		case i == len(fieldData)-1 && (zone == 0xC || zone == 0xA || zone == 0xE):
+		case i == len(fieldData)-1 && (zone == 0xD || zone == 0xB):
+			negative = true
+		default:
+			return errors.Errorf("flatfile.assignZoned: Invalid zone 0x%02X at byte %d", b, i+1)
+		}
+		if value > (math.MaxInt64-int64(digit))/10 {
+			return errors.Errorf("flatfile.assignZoned: Value of % X overflows int64", fieldData)
+		}
+		value = value*10 + int64(digit)
+	}
+	if negative {
+		value = -value
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if field.OverflowInt(value) {
+			return errors.Errorf("flatfile.assignZoned: %d overflows %s", value, field.Type())
+		}
+		field.SetInt(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value < 0 || field.OverflowUint(uint64(value)) {
+			return errors.Errorf("flatfile.assignZoned: %d does not fit in %s", value, field.Type())
+		}
+		field.SetUint(uint64(value))
+	default:
+		return errors.Errorf("flatfile.assignZoned: zoned can only be used with an integer field not %s", field.Type())
+	}
+	return nil
+}
+
+//unpackDecimal returns IBM packed decimal (COMP-3) data as decimal text e.g. 12 34 5D with scale 2 is -123.45
+//Each byte holds two digits, one per nibble, except the last whose low nibble is the sign, C, A, E or F for positive and D or B for negative
+//The bytes are read raw so a packed field is unaffected by WithEncoding
+func unpackDecimal(fieldData []byte, scale int) ([]byte, error) {
+	if len(fieldData) == 0 {
+		return nil, errors.New("flatfile.unpackDecimal: Packed decimal field is empty")
+	}
+	digits := make([]byte, 0, 2*len(fieldData))
+	for i, b := range fieldData {
+		high, low := b>>4, b&0x0F
+		if high > 9 || (i < len(fieldData)-1 && low > 9) {
+			return nil, errors.Errorf("flatfile.unpackDecimal: Invalid digit in 0x%02X at byte %d", b, i+1)
+		}
+		digits = append(digits, '0'+high)
+		if i < len(fieldData)-1 {
+			digits = append(digits, '0'+low)
+		}
+	}
+	text := make([]byte, 0, len(digits)+2)
+	switch fieldData[len(fieldData)-1] & 0x0F {
+	case 0xC, 0xA, 0xE, 0xF:
+	case 0xD, 0xB:
+		text = append(text, '-')
+	default:
+		return nil, errors.Errorf("flatfile.unpackDecimal: Invalid sign nibble in 0x%02X at byte %d", fieldData[len(fieldData)-1], len(fieldData))
+	}
+	if scale > len(digits) {
+		return nil, errors.Errorf("flatfile.unpackDecimal: %d implied decimals exceed the %d digits of % X", scale, len(digits), fieldData)
+	}
+	text = append(text, digits[:len(digits)-scale]...)
+	if scale > 0 {
+		text = append(append(text, '.'), digits[len(digits)-scale:]...)
+	}
+	return text, nil
+}
+
+//assignRunLength assigns the number of fill characters at the start of fieldData to a numeric field e.g. ###__ is 3
+//Any other character ends the run so trailing spaces are ignored
+func assignRunLength(field reflect.Value, fieldData []byte, fill byte) error {
+	count := 0
+	for count < len(fieldData) && fieldData[count] == fill {
+		count++
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if field.OverflowInt(int64(count)) {
+			return errors.Errorf("flatfile.assignRunLength: %d overflows %s", count, field.Type())
+		}
+		field.SetInt(int64(count))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if field.OverflowUint(uint64(count)) {
+			return errors.Errorf("flatfile.assignRunLength: %d overflows %s", count, field.Type())
+		}
+		field.SetUint(uint64(count))
+	case reflect.Float32, reflect.Float64:
+		field.SetFloat(float64(count))
+	default:
+		return errors.Errorf("flatfile.assignRunLength: runlen can only be used with a numeric field not %s", field.Type())
+	}
+	return nil
+}
+
+//assignPercent reads a percentage with implied decimals into a float field e.g. 02550 with 3 implied decimals is 2.55
+//The fraction flag divides the percentage by 100 so 2.55% is 0.0255
+func assignPercent(field reflect.Value, fieldData []byte, ffpTag *flatfileTag) error {
+	scaled, err := ParseScaledInt(fieldData, ffpTag.percentScale)
+	if err != nil {
+		return errors.Wrap(err, "flatfile.assignPercent: Error parsing percentage")
+	}
+	field.SetFloat(float64(scaled) / math.Pow10(impliedPercentDecimals(ffpTag)))
+	return nil
+}
+
+//impliedPercentDecimals returns the number of implied decimals of a percent field, 2 more for a fraction
+func impliedPercentDecimals(ffpTag *flatfileTag) int {
+	if ffpTag.fraction {
+		return ffpTag.percentScale + 2
+	}
+	return ffpTag.percentScale
+}
+
+//applySign negates the numeric field when signData is - or D (debit). + or C (credit) and blank leave it positive
+func applySign(field reflect.Value, signData []byte) error {
+	var negative bool
+	switch sign := strings.TrimSpace(string(signData)); sign {
+	case "-", "D":
+		negative = true
+	case "+", "C", "":
+		negative = false
+	default:
+		return errors.Errorf("flatfile.applySign: Invalid sign %q. Expected +, -, C or D", sign)
+	}
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return nil
+		}
+		field = field.Elem()
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if negative {
+			field.SetInt(-field.Int())
+		}
+	case reflect.Float32, reflect.Float64:
+		if negative {
+			field.SetFloat(-field.Float())
+		}
+	default:
+		return errors.Errorf("flatfile.applySign: A sign cannot be applied to %s", field.Type())
+	}
+	return nil
+}
+
+//isRuneSequence returns true for a []rune or [N]rune field using the rune override
+//These fields are decoded one rune per character instead of one rune per len bytes
+func isRuneSequence(t reflect.Type, ffpTag *flatfileTag) bool {
+	return ffpTag.override == "rune" && t.Elem().Kind() == reflect.Int32
+}
+
+//assignRunes decodes the UTF-8 fieldData into the runes of a []rune or [N]rune field
+//A slice holds every rune decoded. An array holds up to its length in runes and any remaining elements are zero
+func assignRunes(field reflect.Value, fieldData []byte) error {
+	var runes []rune
+	for len(fieldData) > 0 {
+		r, size := utf8.DecodeRune(fieldData)
+		if r == utf8.RuneError && size <= 1 {
+			return errors.Errorf("flatfile.assignRunes: Invalid UTF-8 at rune %d", len(runes))
+		}
+		runes = append(runes, r)
+		fieldData = fieldData[size:]
+	}
+	if field.Kind() == reflect.Slice {
+		field.Set(reflect.MakeSlice(field.Type(), len(runes), len(runes)))
+	}
+	for i := 0; i < field.Len(); i++ {
+		if i < len(runes) {
+			field.Index(i).SetInt(int64(runes[i]))
+		} else {
+			field.Index(i).SetInt(0)
+		}
+	}
+	return nil
+}
+
+//fieldWidth returns the number of bytes a field of type t occupies in the record
+//Repeating fields occupy the width of one element multiplied by the number of elements
+func fieldWidth(t reflect.Type, ffpTag *flatfileTag) int {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return fieldWidth(t.Elem(), ffpTag)
+	case reflect.Array:
+		return t.Len() * elementWidth(t.Elem(), ffpTag)
+	case reflect.Slice:
+		if ffpTag.occurs > 0 {
+			return ffpTag.occurs * elementWidth(t.Elem(), ffpTag)
+		}
+	}
+	return ffpTag.length
+}
+
+//elementWidth returns the number of bytes one element of type t of a repeating field occupies
+//Elements that are themselves arrays or slices are laid out row-major, each row being len * columns bytes
+func elementWidth(t reflect.Type, ffpTag *flatfileTag) int {
+	switch t.Kind() {
+	case reflect.Array:
+		return t.Len() * ffpTag.length
+	case reflect.Slice:
+		return ffpTag.innerOccurs * ffpTag.length
+	}
+	return ffpTag.length
+}
+
+//elementTag returns the tag used to unmarshal one element of type t of a repeating field starting at lowerBound
+//Slice elements take the columns of a rows x columns occurs as their own occurs
+func elementTag(t reflect.Type, ffpTag *flatfileTag, lowerBound int) *flatfileTag {
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		return ffpTag
+	}
+	elemTag := *ffpTag
+	elemTag.col = ffpTag.col + lowerBound
+	elemTag.occurs = ffpTag.innerOccurs
+	elemTag.innerOccurs = 0
+	return &elemTag
+}
+
+//wrapElementError identifies which occurrence of a repeating field failed and the column it starts at
+func wrapElementError(err error, idx int, ffpTag *flatfileTag, lowerBound int) error {
+	return errors.Wrapf(err, "flatfile.assignBasedOnKind: Failed to unmarshal element %d at col %d", idx, ffpTag.col+lowerBound)
+}
+
+//splitLengthPrefix reads the first prefixLen bytes of fieldData as a decimal length and returns that many bytes following the prefix
+func splitLengthPrefix(fieldData []byte, prefixLen int) ([]byte, error) {
+	if len(fieldData) < prefixLen {
+		return nil, errors.Errorf("flatfile.splitLengthPrefix: Expected %d byte length prefix but only %d bytes remain", prefixLen, len(fieldData))
+	}
+	valueLen, err := strconv.Atoi(string(fieldData[:prefixLen]))
+	if err != nil {
+		return nil, errors.Wrapf(err, "flatfile.splitLengthPrefix: Error parsing length prefix %s", fieldData[:prefixLen])
+	}
+	if valueLen < 0 || prefixLen+valueLen > len(fieldData) {
+		return nil, errors.Errorf("flatfile.splitLengthPrefix: Length prefix %d exceeds the %d bytes remaining", valueLen, len(fieldData)-prefixLen)
+	}
+	return fieldData[prefixLen : prefixLen+valueLen], nil
+}
+
+//assignBool compares the field data with surrounding whitespace removed so padded flags such as "Y " parse
+//A blank field is false. If the tag maps true and false values e.g. `true=Y,false=N` only those values are accepted
+//Zero padded 0 and 1 flags such as 01 are accepted. With `boolmode=numeric` any nonzero number is true
+func assignBool(kind reflect.Kind, field reflect.Value, fieldData []byte, ffpTag *flatfileTag, o *unmarshalOptions) error {
+	boolData := strings.TrimSpace(string(fieldData))
+	trueVal, falseVal := ffpTag.trueVal, ffpTag.falseVal
+	if trueVal == "" && falseVal == "" && !ffpTag.boolNumeric {
+		trueVal, falseVal = o.trueText, o.falseText
+	}
+	var newFieldVal bool
+	var err error
+	switch {
+	case boolData == "":
+		newFieldVal = false
+	case trueVal != "" || falseVal != "":
+		if boolData == trueVal {
+			newFieldVal = true
+		} else if boolData != falseVal {
+			err = errors.Errorf("flatfile.assignBool: %q is neither the true value %q nor the false value %q", boolData, trueVal, falseVal)
+		}
+	case ffpTag.boolNumeric:
+		var number float64
+		number, err = strconv.ParseFloat(boolData, 64)
+		newFieldVal = number != 0
+	case strings.Trim(boolData, "0123456789") == "":
+		//zero padded flags such as 01 and 00
+		switch strings.TrimLeft(boolData, "0") {
+		case "":
+			newFieldVal = false
+		case "1":
+			newFieldVal = true
+		default:
+			err = errors.Errorf("flatfile.assignBool: %q is not 0 or 1. Use boolmode=numeric to read any nonzero number as true", boolData)
+		}
+	default:
+		newFieldVal, err = strconv.ParseBool(boolData)
+	}
 	if err == nil {
-		field.Set(reflect.ValueOf(newFieldVal))
+		field.SetBool(newFieldVal)
 	}
 
 	return errors.Wrap(err, "flatfile.assignBool error")
@@ -101,16 +687,17 @@ func assignUint(kind reflect.Kind, field reflect.Value, fieldData []byte) error
 	//Determine bitness using Sizeof
 	//this will return 1 for 8-bit, 2 for 16-bit, 4 for 32-bit, 8 for 64-bit. Multiply the result to get bitsize and convert to int for Parsing
 	newFieldVal, err := strconv.ParseUint(string(fieldData), 10, int(unsafe.Sizeof(dummy)*8))
-	if err == nil {
-		field.Set(reflect.ValueOf(uint(newFieldVal)))
+	if err != nil {
+		return errors.Wrapf(err, "flatfile.assignUint: Failed to assignUint %v ", field)
 	}
-	return errors.Wrapf(err, "flatfile.assignUint: Failed to assignUint %v ", field)
+	field.SetUint(newFieldVal)
+	return nil
 }
 
 func assignUint8(kind reflect.Kind, field reflect.Value, fieldData []byte) error {
 	newFieldVal, err := strconv.ParseUint(string(fieldData), 10, 8)
 	if err == nil {
-		field.Set(reflect.ValueOf(uint8(newFieldVal)))
+		field.SetUint(newFieldVal)
 	}
 	return errors.Wrap(err, "flatfile.assignUint8 error")
 }
@@ -118,7 +705,7 @@ func assignUint8(kind reflect.Kind, field reflect.Value, fieldData []byte) error
 func assignUint16(kind reflect.Kind, field reflect.Value, fieldData []byte) error {
 	newFieldVal, err := strconv.ParseUint(string(fieldData), 10, 16)
 	if err == nil {
-		field.Set(reflect.ValueOf(uint16(newFieldVal)))
+		field.SetUint(newFieldVal)
 	}
 	return errors.Wrap(err, "flatfile.assignUint16 error")
 }
@@ -126,7 +713,7 @@ func assignUint16(kind reflect.Kind, field reflect.Value, fieldData []byte) erro
 func assignUint32(kind reflect.Kind, field reflect.Value, fieldData []byte) error {
 	newFieldVal, err := strconv.ParseUint(string(fieldData), 10, 32)
 	if err == nil {
-		field.Set(reflect.ValueOf(uint32(newFieldVal)))
+		field.SetUint(newFieldVal)
 	}
 	return errors.Wrap(err, "flatfile.assignUint32 error")
 }
@@ -134,7 +721,7 @@ func assignUint32(kind reflect.Kind, field reflect.Value, fieldData []byte) erro
 func assignUint64(kind reflect.Kind, field reflect.Value, fieldData []byte) error {
 	newFieldVal, err := strconv.ParseUint(string(fieldData), 10, 64)
 	if err == nil {
-		field.Set(reflect.ValueOf(newFieldVal))
+		field.SetUint(newFieldVal)
 	}
 	return errors.Wrap(err, "flatfile.assignUint64 error")
 }
@@ -144,16 +731,17 @@ func assignInt(kind reflect.Kind, field reflect.Value, fieldData []byte) error {
 	//Determine bitness using Sizeof
 	//this will return 1 for 8-bit, 2 for 16-bit, 4 for 32-bit, 8 for 64-bit. Multiply the result to get bitsize and convert to int for Parsing
 	newFieldVal, err := strconv.ParseInt(string(fieldData), 10, int(unsafe.Sizeof(dummy)*8))
-	if err == nil {
-		field.Set(reflect.ValueOf(int(newFieldVal)))
+	if err != nil {
+		return errors.Wrapf(err, "flatfile.assignInt: Failed to assignInt %v ", field)
 	}
-	return errors.Wrapf(err, "flatfile.assignInt: Failed to assignInt %v ", field)
+	field.SetInt(newFieldVal)
+	return nil
 }
 
 func assignInt8(kind reflect.Kind, field reflect.Value, fieldData []byte) error {
 	newFieldVal, err := strconv.ParseInt(string(fieldData), 10, 8)
 	if err == nil {
-		field.Set(reflect.ValueOf(int8(newFieldVal)))
+		field.SetInt(newFieldVal)
 	}
 	return errors.Wrap(err, "flatfile.assignInt8 error")
 }
@@ -161,7 +749,7 @@ func assignInt8(kind reflect.Kind, field reflect.Value, fieldData []byte) error
 func assignInt16(kind reflect.Kind, field reflect.Value, fieldData []byte) error {
 	newFieldVal, err := strconv.ParseInt(string(fieldData), 10, 16)
 	if err == nil {
-		field.Set(reflect.ValueOf(int16(newFieldVal)))
+		field.SetInt(newFieldVal)
 	}
 	return errors.Wrap(err, "flatfile.assignInt16 error")
 }
@@ -169,7 +757,7 @@ func assignInt16(kind reflect.Kind, field reflect.Value, fieldData []byte) error
 func assignInt32(kind reflect.Kind, field reflect.Value, fieldData []byte) error {
 	newFieldVal, err := strconv.ParseInt(string(fieldData), 10, 32)
 	if err == nil {
-		field.Set(reflect.ValueOf(int32(newFieldVal)))
+		field.SetInt(newFieldVal)
 	}
 	return errors.Wrap(err, "flatfile.assignInt32 error")
 }
@@ -177,7 +765,7 @@ func assignInt32(kind reflect.Kind, field reflect.Value, fieldData []byte) error
 func assignInt64(kind reflect.Kind, field reflect.Value, fieldData []byte) error {
 	newFieldVal, err := strconv.ParseInt(string(fieldData), 10, 64)
 	if err == nil {
-		field.Set(reflect.ValueOf(int64(newFieldVal)))
+		field.SetInt(newFieldVal)
 	}
 	return errors.Wrap(err, "flatfile.assignInt64 error")
 }
@@ -185,7 +773,7 @@ func assignInt64(kind reflect.Kind, field reflect.Value, fieldData []byte) error
 func assignFloat32(kind reflect.Kind, field reflect.Value, fieldData []byte) error {
 	newFieldVal, err := strconv.ParseFloat(string(fieldData), 32)
 	if err == nil {
-		field.Set(reflect.ValueOf(float32(newFieldVal)))
+		field.SetFloat(newFieldVal)
 	}
 	return errors.Wrap(err, "flatfile.assignFloat32 error")
 }
@@ -193,13 +781,13 @@ func assignFloat32(kind reflect.Kind, field reflect.Value, fieldData []byte) err
 func assignFloat64(kind reflect.Kind, field reflect.Value, fieldData []byte) error {
 	newFieldVal, err := strconv.ParseFloat(string(fieldData), 64)
 	if err == nil {
-		field.Set(reflect.ValueOf(newFieldVal))
+		field.SetFloat(newFieldVal)
 	}
 	return errors.Wrap(err, "flatfile.assignFloat64 error")
 }
 
 func assignByte(field reflect.Value, fieldData byte) error {
-	field.Set(reflect.ValueOf(fieldData))
+	field.SetUint(uint64(fieldData))
 	return nil
 }
 
@@ -208,6 +796,6 @@ func assignRune(field reflect.Value, fieldData []byte) error {
 	if newFieldVal == utf8.RuneError {
 		return errors.New("flatfile.assignRune error")
 	}
-	field.Set(reflect.ValueOf(newFieldVal))
+	field.SetInt(int64(newFieldVal))
 	return nil
 }
@@ -0,0 +1,143 @@
+package flatfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+//BatchLayout describes a file of one header record, any number of detail records and one trailer record
+type BatchLayout struct {
+	//TypeCol and TypeLen locate the record type code at the start of each line
+	TypeCol int
+	TypeLen int
+	//HeaderType and TrailerType are the type codes of the header and trailer. Every other type is a detail
+	HeaderType  string
+	TrailerType string
+	//Dispatch returns a pointer to a new struct to unmarshal a record of that type into, or nil if the type is unknown
+	Dispatch func(recordType string) interface{}
+	//CountField is the trailer field holding the number of detail records. Left empty the count is not checked
+	CountField string
+	//HashField is the detail field summed into the hash total held by the trailer field HashTotalField
+	//Left empty the hash total is not checked
+	HashField      string
+	HashTotalField string
+}
+
+//Batch is a header, detail and trailer file read by ParseBatch
+type Batch struct {
+	Header  interface{}
+	Details []interface{}
+	Trailer interface{}
+	//Mismatches describes each trailer control total that does not agree with the details
+	Mismatches []string
+}
+
+//Valid returns true if the trailer control totals agree with the details
+func (b *Batch) Valid() bool {
+	return len(b.Mismatches) == 0
+}
+
+//ParseBatch reads a header, detail and trailer file from r one line per record and checks the trailer control totals
+//Records are unmarshalled into the struct Dispatch returns for their type code
+//An error is returned when the file is not a header, details and a trailer in that order or a record cannot be read
+//Control totals that do not agree are reported by Batch.Mismatches rather than as an error
+func ParseBatch(r io.Reader, layout BatchLayout) (*Batch, error) {
+	if layout.TypeCol < 1 || layout.TypeLen < 1 {
+		return nil, errors.Errorf("flatfile.ParseBatch: Out of range error. Type column %d and length %d cannot be less than 1", layout.TypeCol, layout.TypeLen)
+	}
+	if layout.Dispatch == nil {
+		return nil, errors.New("flatfile.ParseBatch: BatchLayout has no Dispatch")
+	}
+
+	batch := &Batch{}
+	lines := newDecoder(bufio.NewReader(r), "flatfile.ParseBatch")
+	for lineNum := 1; ; lineNum++ {
+		line, err := lines.readRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return batch, err
+		}
+		if len(line) < layout.TypeCol-1+layout.TypeLen {
+			return batch, errors.Errorf("flatfile.ParseBatch: Line %d is too short to contain a record type", lineNum)
+		}
+		recordType := string(line[layout.TypeCol-1 : layout.TypeCol-1+layout.TypeLen])
+		switch {
+		case batch.Trailer != nil:
+			return batch, errors.Errorf("flatfile.ParseBatch: Record type %q on line %d follows the trailer", recordType, lineNum)
+		case lineNum == 1 && recordType != layout.HeaderType:
+			return batch, errors.Errorf("flatfile.ParseBatch: Expected header type %q on line 1 but got %q", layout.HeaderType, recordType)
+		case lineNum > 1 && recordType == layout.HeaderType:
+			return batch, errors.Errorf("flatfile.ParseBatch: Header type %q repeated on line %d", recordType, lineNum)
+		}
+
+		record := layout.Dispatch(recordType)
+		if record == nil {
+			return batch, errors.Errorf("flatfile.ParseBatch: Unknown record type %q on line %d", recordType, lineNum)
+		}
+		if recordValue := reflect.ValueOf(record); recordValue.Kind() != reflect.Ptr || recordValue.Elem().Kind() != reflect.Struct {
+			return batch, errors.Errorf("flatfile.ParseBatch: Record type %q on line %d dispatched %T which is not a pointer to a struct", recordType, lineNum, record)
+		}
+		if err := Unmarshal(line, record, 0, 0, false); err != nil {
+			return batch, errors.Wrapf(err, "flatfile.ParseBatch: Failed to unmarshal record type %q on line %d", recordType, lineNum)
+		}
+		switch recordType {
+		case layout.HeaderType:
+			batch.Header = record
+		case layout.TrailerType:
+			batch.Trailer = record
+		default:
+			batch.Details = append(batch.Details, record)
+		}
+	}
+	if batch.Trailer == nil {
+		return batch, errors.Errorf("flatfile.ParseBatch: Batch has no trailer type %q", layout.TrailerType)
+	}
+
+	return batch, batch.checkTotals(&layout)
+}
+
+//checkTotals compares the record count and hash total of the trailer with the details
+func (b *Batch) checkTotals(layout *BatchLayout) error {
+	if layout.CountField != "" {
+		count, err := recordFieldInt(b.Trailer, layout.CountField)
+		if err != nil {
+			return errors.Wrap(err, "flatfile.ParseBatch: Trailer count")
+		}
+		if count != int64(len(b.Details)) {
+			b.Mismatches = append(b.Mismatches, fmt.Sprintf("Trailer %s is %d but the batch has %d detail records", layout.CountField, count, len(b.Details)))
+		}
+	}
+	if layout.HashField != "" {
+		hashTotal, err := recordFieldInt(b.Trailer, layout.HashTotalField)
+		if err != nil {
+			return errors.Wrap(err, "flatfile.ParseBatch: Trailer hash total")
+		}
+		var sum int64
+		for idx, detail := range b.Details {
+			value, err := recordFieldInt(detail, layout.HashField)
+			if err != nil {
+				return errors.Wrapf(err, "flatfile.ParseBatch: Detail record %d hash field", idx+1)
+			}
+			sum += value
+		}
+		if sum != hashTotal {
+			b.Mismatches = append(b.Mismatches, fmt.Sprintf("Trailer %s is %d but the detail %s fields sum to %d", layout.HashTotalField, hashTotal, layout.HashField, sum))
+		}
+	}
+	return nil
+}
+
+//recordFieldInt returns the integer value of the field named fieldName of record, a pointer to a struct
+func recordFieldInt(record interface{}, fieldName string) (int64, error) {
+	field := reflect.ValueOf(record).Elem().FieldByName(fieldName)
+	if !field.IsValid() {
+		return 0, errors.Errorf("flatfile.recordFieldInt: %T has no field %s", record, fieldName)
+	}
+	return intFieldValue(field)
+}
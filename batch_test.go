@@ -0,0 +1,113 @@
+package flatfile
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testBatchTrailer struct {
+	Type      string `flatfile:"1,1"`
+	Count     int    `flatfile:"2,2"`
+	HashTotal string `flatfile:"4,6"`
+}
+
+func testBatchDispatch(recordType string) interface{} {
+	if recordType == "T" {
+		return &testBatchTrailer{}
+	}
+	return testDispatch(recordType)
+}
+
+var testBatchLayout = BatchLayout{
+	TypeCol:        1,
+	TypeLen:        1,
+	HeaderType:     "H",
+	TrailerType:    "T",
+	Dispatch:       testBatchDispatch,
+	CountField:     "Count",
+	HashField:      "Amount",
+	HashTotalField: "HashTotal",
+}
+
+func TestParseBatch(t *testing.T) {
+	data := "H20200101\r\nDAMY0100\r\nDBOB0250\r\nT02000350\r\n"
+
+	batch, err := ParseBatch(strings.NewReader(data), testBatchLayout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Batch{
+		Header:  &testHeader{Type: "H", Date: "20200101"},
+		Details: []interface{}{&testDetail{Type: "D", Name: "AMY", Amount: 100}, &testDetail{Type: "D", Name: "BOB", Amount: 250}},
+		Trailer: &testBatchTrailer{Type: "T", Count: 2, HashTotal: "000350"},
+	}
+	if !reflect.DeepEqual(batch, want) || !batch.Valid() {
+		t.Errorf("ParseBatch(%q) got: %+v want: %+v", data, batch, want)
+	}
+}
+
+func TestParseBatchMismatch(t *testing.T) {
+	data := "H20200101\nDAMY0100\nDBOB0250\nT03000300\n"
+
+	batch, err := ParseBatch(strings.NewReader(data), testBatchLayout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch.Valid() || len(batch.Mismatches) != 2 {
+		t.Errorf("ParseBatch(%q) should report the count and hash total mismatches got: %v", data, batch.Mismatches)
+	}
+}
+
+func TestParseBatchErr(t *testing.T) {
+	var tests = []struct {
+		Data    string
+		WantMsg string
+	}{
+		{"DAMY0100\nT01000100\n", "Expected header type \"H\" on line 1"},
+		{"H20200101\nDAMY0100\n", "Batch has no trailer"},
+		{"H20200101\nT00000000\nDAMY0100\n", "follows the trailer"},
+		{"H20200101\nH20200102\nT00000000\n", "Header type \"H\" repeated on line 2"},
+		{"H20200101\nXAMY0100\nT01000100\n", "Unknown record type \"X\" on line 2"},
+		{"H20200101\nDAMYXXXX\nT01000100\n", "Failed to unmarshal record type \"D\" on line 2"},
+		{"H20200101\nDAMY0100\nT01ABCDEF\n", "Trailer hash total"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestParseBatchErr-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			_, err := ParseBatch(strings.NewReader(tt.Data), testBatchLayout)
+			if err == nil || !strings.Contains(err.Error(), tt.WantMsg) {
+				t.Errorf("ParseBatch(%q) err: %v want message containing: %s", tt.Data, err, tt.WantMsg)
+			}
+		})
+	}
+}
+
+func TestParseBatchDispatchErr(t *testing.T) {
+	var tests = []struct {
+		Record  interface{}
+		WantMsg string
+	}{
+		{new(int), "Record type \"D\" on line 2 dispatched *int which is not a pointer to a struct"},
+		{testBatchTrailer{}, "Record type \"D\" on line 2 dispatched flatfile.testBatchTrailer which is not a pointer to a struct"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestParseBatchDispatchErr-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			layout := testBatchLayout
+			layout.Dispatch = func(recordType string) interface{} {
+				if recordType == "D" {
+					return tt.Record
+				}
+				return testBatchDispatch(recordType)
+			}
+			_, err := ParseBatch(strings.NewReader("H20200101\nDAMY0100\nT01000100\n"), layout)
+			if err == nil || !strings.Contains(err.Error(), tt.WantMsg) {
+				t.Errorf("ParseBatch err: %v want message containing: %s", err, tt.WantMsg)
+			}
+		})
+	}
+}
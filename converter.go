@@ -0,0 +1,131 @@
+package flatfile
+
+import (
+	"encoding/hex"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+//Converter converts the data of a single field into the value assigned to that field
+type Converter func(fieldData []byte) (interface{}, error)
+
+//converterRegistry maps a converter name used in the conv tag option to its Converter
+//The builtin ip and uuid converters are registered from the start and can be replaced with RegisterConverter
+var converterRegistry = struct {
+	sync.RWMutex
+	converters map[string]Converter
+}{converters: map[string]Converter{
+	"ip":   convertIP,
+	"uuid": convertUUID,
+}}
+
+//RegisterConverter names a Converter so it can be used by individual fields with the conv tag option
+//This is useful for types that cannot be modified and only need special handling on some fields
+//For example:
+//flatfile.RegisterConverter("parseAccount", func(data []byte) (interface{}, error) { return account.Parse(string(data)) })
+//type Transfer struct {
+//		From account.Number `flatfile:"1,20,,conv=parseAccount"`
+//}
+//Converters must be registered before a struct using them is first unmarshalled
+//Repeating fields convert each element
+func RegisterConverter(name string, conv Converter) error {
+	if name == "" || conv == nil {
+		return errors.New("flatfile.RegisterConverter: Converter name and func must be provided")
+	}
+	converterRegistry.Lock()
+	defer converterRegistry.Unlock()
+	converterRegistry.converters[name] = conv
+	return nil
+}
+
+//lookupConverter returns the Converter registered as name
+func lookupConverter(name string) (Converter, bool) {
+	converterRegistry.RLock()
+	defer converterRegistry.RUnlock()
+	conv, ok := converterRegistry.converters[name]
+	return conv, ok
+}
+
+//assignConverted assigns the value returned by the converter named in the tag to field
+//A nil value leaves the field as its zero value
+func assignConverted(field reflect.Value, fieldData []byte, name string) error {
+	conv, ok := lookupConverter(name)
+	if !ok {
+		return errors.Errorf("flatfile.assignConverted: Converter %s is not registered", name)
+	}
+	value, err := conv(fieldData)
+	if err != nil {
+		return errors.Wrapf(err, "flatfile.assignConverted: Converter %s failed", name)
+	}
+	if value == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	v := reflect.ValueOf(value)
+	switch {
+	case v.Type().AssignableTo(field.Type()):
+		field.Set(v)
+	case v.Type().ConvertibleTo(field.Type()):
+		field.Set(v.Convert(field.Type()))
+	case field.Kind() == reflect.Ptr && v.Type().AssignableTo(field.Type().Elem()):
+		ptr := reflect.New(field.Type().Elem())
+		ptr.Elem().Set(v)
+		field.Set(ptr)
+	default:
+		return errors.Errorf("flatfile.assignConverted: Converter %s returned %s which cannot be assigned to %s", name, v.Type(), field.Type())
+	}
+	return nil
+}
+
+//isRepeating returns true for fields whose elements are unmarshalled individually
+func isRepeating(kind reflect.Kind, ffpTag *flatfileTag) bool {
+	return kind == reflect.Array || (kind == reflect.Slice && ffpTag.occurs != 0)
+}
+
+//convertIP is the builtin ip converter returning a net.IP e.g. `flatfile:"1,15,,ip"`
+//It reads a dotted quad whose parts may be zero padded e.g. 192.168.000.001, 8 hex digits e.g. C0A80001 or an IPv6 address
+//A blank field is a nil net.IP
+func convertIP(fieldData []byte) (interface{}, error) {
+	text := strings.TrimSpace(string(fieldData))
+	if text == "" {
+		return nil, nil
+	}
+	if parts := strings.Split(text, "."); len(parts) == 4 {
+		var octets [4]byte
+		for i, part := range parts {
+			octet, err := strconv.ParseUint(part, 10, 8)
+			if err != nil {
+				return nil, errors.Errorf("flatfile.convertIP: Invalid IPv4 address %q", text)
+			}
+			octets[i] = byte(octet)
+		}
+		return net.IPv4(octets[0], octets[1], octets[2], octets[3]), nil
+	}
+	if octets, err := hex.DecodeString(text); err == nil && len(octets) == 4 {
+		return net.IPv4(octets[0], octets[1], octets[2], octets[3]), nil
+	}
+	if ip := net.ParseIP(text); ip != nil {
+		return ip, nil
+	}
+	return nil, errors.Errorf("flatfile.convertIP: Invalid IP address %q", text)
+}
+
+//convertUUID is the builtin uuid converter returning the 16 bytes of a UUID for a []byte field e.g. `flatfile:"1,36,,uuid"`
+//It reads 32 hex digits that may be separated by hyphens e.g. 123e4567-e89b-12d3-a456-426614174000. A blank field is nil
+//An array field such as [16]byte repeats so it cannot be used, the converter would be given each byte
+func convertUUID(fieldData []byte) (interface{}, error) {
+	text := strings.TrimSpace(string(fieldData))
+	if text == "" {
+		return nil, nil
+	}
+	uuid, err := hex.DecodeString(strings.Replace(text, "-", "", -1))
+	if err != nil || len(uuid) != 16 {
+		return nil, errors.Errorf("flatfile.convertUUID: Invalid UUID %q", text)
+	}
+	return uuid, nil
+}
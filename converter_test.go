@@ -0,0 +1,134 @@
+package flatfile
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+//testAccount stands in for a third-party type that cannot implement an interface
+type testAccount struct {
+	Branch string
+	Number string
+}
+
+func init() {
+	RegisterConverter("testParseAccount", func(data []byte) (interface{}, error) {
+		s := strings.TrimSpace(string(data))
+		parts := strings.Split(s, "-")
+		if len(parts) != 2 {
+			return nil, errors.Errorf("invalid account %q", s)
+		}
+		return testAccount{Branch: parts[0], Number: parts[1]}, nil
+	})
+}
+
+type testConverted struct {
+	From     testAccount    `flatfile:"1,8,,conv=testParseAccount"`
+	To       *testAccount   `flatfile:"9,8,,conv=testParseAccount"`
+	Accounts []testAccount  `flatfile:"17,8,2,conv=testParseAccount"`
+	Pair     [2]testAccount `flatfile:"17,8,,conv=testParseAccount"`
+}
+
+func TestUnmarshalConverter(t *testing.T) {
+	data := []byte("001-1234002-5678003-0001004-0002")
+	var got testConverted
+	if err := Unmarshal(data, &got, 0, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	accounts := []testAccount{{"003", "0001"}, {"004", "0002"}}
+	want := testConverted{
+		From:     testAccount{"001", "1234"},
+		To:       &testAccount{"002", "5678"},
+		Accounts: accounts,
+		Pair:     [2]testAccount{accounts[0], accounts[1]},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal(%s) got: %+v want: %+v", data, got, want)
+	}
+}
+
+func TestUnmarshalConverterErr(t *testing.T) {
+	var tests = []struct {
+		Data    string
+		V       interface{}
+		WantMsg string
+	}{
+		{"0011234 ", &struct {
+			From testAccount `flatfile:"1,8,,conv=testParseAccount"`
+		}{}, "Converter testParseAccount failed: invalid account \"0011234\""},
+		{"001-1234", &struct {
+			From int `flatfile:"1,8,,conv=testParseAccount"`
+		}{}, "returned flatfile.testAccount which cannot be assigned to int"},
+		{"001-1234", &struct {
+			From testAccount `flatfile:"1,8,,conv=testMissing"`
+		}{}, "Converter testMissing is not registered"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestUnmarshalConverterErr-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			err := Unmarshal([]byte(tt.Data), tt.V, 0, 0, false)
+			if err == nil || !strings.Contains(err.Error(), tt.WantMsg) {
+				t.Errorf("Unmarshal(%s) err: %v want message containing: %s", tt.Data, err, tt.WantMsg)
+			}
+		})
+	}
+}
+
+func TestBuiltinConverters(t *testing.T) {
+	type FfpTest struct {
+		Host  net.IP  `flatfile:"1,15,,ip"`
+		Hex   net.IP  `flatfile:"16,8,,conv=ip"`
+		V6    *net.IP `flatfile:"24,11,,ip"`
+		Blank net.IP  `flatfile:"35,7,,ip"`
+		ID    []byte  `flatfile:"42,36,,uuid"`
+	}
+
+	data := []byte("192.168.000.001C0A80001fe80::1:2  " + "       " + "123e4567-e89b-12d3-a456-426614174000")
+	got := FfpTest{}
+	if err := Unmarshal(data, &got, 0, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Host.Equal(net.IPv4(192, 168, 0, 1)) || !got.Hex.Equal(net.IPv4(192, 168, 0, 1)) || got.Blank != nil {
+		t.Errorf("Unmarshal(%s) got: %+v", data, got)
+	}
+	if got.V6 == nil || !got.V6.Equal(net.ParseIP("fe80::1:2")) {
+		t.Errorf("Unmarshal(%s) V6 got: %v want: fe80::1:2", data, got.V6)
+	}
+	wantID := []byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+	if !bytes.Equal(got.ID, wantID) {
+		t.Errorf("Unmarshal(%s) ID got: %x want: %x", data, got.ID, wantID)
+	}
+
+	var tests = []struct {
+		Data    string
+		V       interface{}
+		WantMsg string
+	}{
+		{"192.168.0.256  ", &struct {
+			Host net.IP `flatfile:"1,15,,ip"`
+		}{}, "Invalid IPv4 address \"192.168.0.256\""},
+		{"localhost      ", &struct {
+			Host net.IP `flatfile:"1,15,,ip"`
+		}{}, "Invalid IP address \"localhost\""},
+		{"123e4567-e89b-12d3", &struct {
+			ID []byte `flatfile:"1,18,,uuid"`
+		}{}, "Invalid UUID \"123e4567-e89b-12d3\""},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestBuiltinConverters-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			err := Unmarshal([]byte(tt.Data), tt.V, 0, 0, false)
+			if err == nil || !strings.Contains(err.Error(), tt.WantMsg) {
+				t.Errorf("Unmarshal(%s) err: %v want message containing: %s", tt.Data, err, tt.WantMsg)
+			}
+		})
+	}
+}
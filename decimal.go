@@ -0,0 +1,156 @@
+package flatfile
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//FieldUnmarshaler is implemented by types that unmarshal their own field data
+//A field whose address implements FieldUnmarshaler is passed its raw data instead of being assigned based on its kind
+//This is the hook for precise money types. For example a decimal stored as an int64 of cents:
+//type Money struct {
+//		cents int64
+//}
+//func (m *Money) UnmarshalFlatfileField(fieldData []byte) (err error) {
+//		m.cents, err = flatfile.ParseScaledInt(fieldData, 2)
+//		return err
+//}
+type FieldUnmarshaler interface {
+	UnmarshalFlatfileField(fieldData []byte) error
+}
+
+var fieldUnmarshalerType = reflect.TypeOf((*FieldUnmarshaler)(nil)).Elem()
+
+//implementsFieldUnmarshaler returns true if a pointer to type t implements FieldUnmarshaler
+func implementsFieldUnmarshaler(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(fieldUnmarshalerType)
+}
+
+//Cents is an amount of money with 2 implied decimals held as an integer number of cents so no precision is lost to float
+//A field of type Cents reads "0012345" or "123.45" as 12345 e.g. `flatfile:"1,7"`
+type Cents int64
+
+//UnmarshalFlatfileField reads fieldData as a decimal amount with 2 implied decimals
+func (c *Cents) UnmarshalFlatfileField(fieldData []byte) error {
+	cents, err := ParseScaledInt(fieldData, 2)
+	if err != nil {
+		return errors.Wrap(err, "flatfile.Cents.UnmarshalFlatfileField: Error parsing amount")
+	}
+	*c = Cents(cents)
+	return nil
+}
+
+//String formats the amount with its decimal point e.g. Cents(-12345) is "-123.45"
+func (c Cents) String() string {
+	digits := strconv.FormatInt(int64(c), 10)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if len(digits) < 3 {
+		digits = strings.Repeat("0", 3-len(digits)) + digits
+	}
+	return sign + digits[:len(digits)-2] + "." + digits[len(digits)-2:]
+}
+
+//ParseScaledInt reads a decimal number directly into an integer scaled by 10^scale without a float intermediate
+//Data without a decimal point has implied decimals and is returned as is e.g. "012345" with scale 2 is 12345 meaning 123.45
+//Data with a decimal point is scaled up e.g. "123.4" with scale 2 is 12340. More than scale decimals is an error as precision would be lost
+//Surrounding whitespace is ignored and a leading + or - sign is accepted
+func ParseScaledInt(fieldData []byte, scale int) (int64, error) {
+	number := strings.TrimSpace(string(fieldData))
+	if scale < 0 {
+		return 0, errors.Errorf("flatfile.ParseScaledInt: Scale %d cannot be less than 0", scale)
+	}
+	if idx := strings.IndexByte(number, '.'); idx >= 0 {
+		decimals := number[idx+1:]
+		if len(decimals) > scale {
+			return 0, errors.Errorf("flatfile.ParseScaledInt: %q has more than %d decimals", number, scale)
+		}
+		number = number[:idx] + decimals + strings.Repeat("0", scale-len(decimals))
+	}
+	scaled, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "flatfile.ParseScaledInt: Error parsing %q", string(fieldData))
+	}
+	return scaled, nil
+}
+
+//roundDecimal rounds the decimal text number, as written by strconv.FormatFloat with 'f', to exactly decimals decimals
+//Rounding is done on the decimal digits so 2.345 is rounded as written rather than as its nearest binary float 2.34499...
+//A number rounded to zero loses its minus sign
+func roundDecimal(number string, decimals int, mode RoundingMode) (string, error) {
+	sign := ""
+	if strings.HasPrefix(number, "-") {
+		sign, number = "-", number[1:]
+	}
+	intPart, fraction := number, ""
+	if idx := strings.IndexByte(number, '.'); idx >= 0 {
+		intPart, fraction = number[:idx], number[idx+1:]
+	}
+	if intPart == "" || strings.Trim(intPart+fraction, "0123456789") != "" {
+		return "", errors.Errorf("flatfile.roundDecimal: %s%s is not a decimal number", sign, number)
+	}
+	if len(fraction) < decimals {
+		fraction += strings.Repeat("0", decimals-len(fraction))
+	}
+	digits, dropped := []byte(intPart+fraction[:decimals]), fraction[decimals:]
+
+	var roundUp bool
+	switch mode {
+	case RoundHalfUp:
+		roundUp = dropped != "" && dropped[0] >= '5'
+	case RoundHalfEven:
+		lastOdd := (digits[len(digits)-1]-'0')%2 == 1
+		roundUp = dropped != "" && (dropped[0] > '5' || (dropped[0] == '5' && (strings.TrimRight(dropped[1:], "0") != "" || lastOdd)))
+	case RoundTruncate:
+	default:
+		return "", errors.Errorf("flatfile.roundDecimal: Unknown rounding mode %d", mode)
+	}
+	if roundUp {
+		idx := len(digits) - 1
+		for ; idx >= 0 && digits[idx] == '9'; idx-- {
+			digits[idx] = '0'
+		}
+		if idx < 0 {
+			digits = append([]byte{'1'}, digits...)
+		} else {
+			digits[idx]++
+		}
+	}
+	if strings.Trim(string(digits), "0") == "" {
+		sign = ""
+	}
+	rounded := string(digits[:len(digits)-decimals])
+	if decimals > 0 {
+		rounded += "." + string(digits[len(digits)-decimals:])
+	}
+	return sign + rounded, nil
+}
+
+//shiftDecimal moves the decimal point of the decimal text number places to the right e.g. 2.55 shifted 3 places is 2550
+//The digits are moved rather than multiplied so no float error is introduced
+func shiftDecimal(number string, places int) string {
+	sign := ""
+	if strings.HasPrefix(number, "-") {
+		sign, number = "-", number[1:]
+	}
+	intPart, fraction := number, ""
+	if idx := strings.IndexByte(number, '.'); idx >= 0 {
+		intPart, fraction = number[:idx], number[idx+1:]
+	}
+	if len(fraction) < places {
+		fraction += strings.Repeat("0", places-len(fraction))
+	}
+	intPart = strings.TrimLeft(intPart+fraction[:places], "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+	if fraction = fraction[places:]; fraction != "" {
+		return sign + intPart + "." + fraction
+	}
+	return sign + intPart
+}
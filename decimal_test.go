@@ -0,0 +1,184 @@
+package flatfile
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// testMoney is the decimal recipe from the FieldUnmarshaler documentation
+type testMoney struct {
+	cents int64
+}
+
+func (m *testMoney) UnmarshalFlatfileField(fieldData []byte) (err error) {
+	m.cents, err = ParseScaledInt(fieldData, 2)
+	return err
+}
+
+func TestParseScaledInt(t *testing.T) {
+	var tests = []struct {
+		Data    string
+		Scale   int
+		Want    int64
+		WantErr bool
+	}{
+		{"0012345", 2, 12345, false},
+		{" 123.45 ", 2, 12345, false},
+		{"123.4", 2, 12340, false},
+		{"-123.", 2, -12300, false},
+		{"+.5", 3, 500, false},
+		{"92233720368547758.07", 2, 9223372036854775807, false},
+		{"0.1", 0, 0, true},
+		{"123.456", 2, 0, true},
+		{"92233720368547758.08", 2, 0, true},
+		{"12a.45", 2, 0, true},
+		{"     ", 2, 0, true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestParseScaledInt-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, err := ParseScaledInt([]byte(tt.Data), tt.Scale)
+			if (err != nil) != tt.WantErr {
+				t.Fatalf("ParseScaledInt(%s,%d) err: %v want err: %v", tt.Data, tt.Scale, err, tt.WantErr)
+			}
+			if got != tt.Want {
+				t.Errorf("ParseScaledInt(%s,%d) got: %d want: %d", tt.Data, tt.Scale, got, tt.Want)
+			}
+		})
+	}
+}
+
+func TestRoundDecimal(t *testing.T) {
+	var tests = []struct {
+		Number   string
+		Decimals int
+		Mode     RoundingMode
+		Want     string
+		WantErr  bool
+	}{
+		{"2.345", 2, RoundHalfUp, "2.35", false},
+		{"2.345", 2, RoundHalfEven, "2.34", false},
+		{"2.345", 2, RoundTruncate, "2.34", false},
+		{"2.355", 2, RoundHalfEven, "2.36", false},
+		{"2.3451", 2, RoundHalfEven, "2.35", false},
+		{"2.344", 2, RoundHalfUp, "2.34", false},
+		{"2.349", 2, RoundTruncate, "2.34", false},
+		{"-2.345", 2, RoundHalfUp, "-2.35", false},
+		{"-2.345", 2, RoundHalfEven, "-2.34", false},
+		{"9.995", 2, RoundHalfUp, "10.00", false},
+		{"2.5", 0, RoundHalfEven, "2", false},
+		{"3.5", 0, RoundHalfEven, "4", false},
+		{"2.5", 0, RoundHalfUp, "3", false},
+		{"2.5", 3, RoundHalfUp, "2.500", false},
+		{"42", 2, RoundHalfUp, "42.00", false},
+		{"-0.004", 2, RoundHalfUp, "0.00", false},
+		{"2.345", 2, RoundingMode(9), "", true},
+		{"NaN", 2, RoundHalfUp, "", true},
+		{"+Inf", 2, RoundHalfUp, "", true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestRoundDecimal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, err := roundDecimal(tt.Number, tt.Decimals, tt.Mode)
+			if (err != nil) != tt.WantErr {
+				t.Fatalf("roundDecimal(%s,%d,%d) err: %v want err: %v", tt.Number, tt.Decimals, tt.Mode, err, tt.WantErr)
+			}
+			if got != tt.Want {
+				t.Errorf("roundDecimal(%s,%d,%d) got: %s want: %s", tt.Number, tt.Decimals, tt.Mode, got, tt.Want)
+			}
+		})
+	}
+}
+
+func TestShiftDecimal(t *testing.T) {
+	var tests = []struct {
+		Number string
+		Places int
+		Want   string
+	}{
+		{"2.55", 3, "2550"},
+		{"0.0255", 5, "2550"},
+		{"-1.5", 2, "-150"},
+		{"2.5555", 3, "2555.5"},
+		{"42", 0, "42"},
+		{"0.001", 1, "0.01"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestShiftDecimal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			if got := shiftDecimal(tt.Number, tt.Places); got != tt.Want {
+				t.Errorf("shiftDecimal(%s,%d) got: %s want: %s", tt.Number, tt.Places, got, tt.Want)
+			}
+		})
+	}
+}
+
+func TestFieldUnmarshaler_Unmarshal(t *testing.T) {
+	type Payment struct {
+		Amount  testMoney   `flatfile:"1,8"`
+		Fee     *testMoney  `flatfile:"9,4"`
+		Refunds []testMoney `flatfile:"13,3,2"`
+	}
+
+	testVal := &Payment{}
+	err := Unmarshal([]byte("00123456 1.5001002"), testVal, 0, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if testVal.Amount.cents != 123456 || testVal.Fee == nil || testVal.Fee.cents != 150 ||
+		len(testVal.Refunds) != 2 || testVal.Refunds[0].cents != 1 || testVal.Refunds[1].cents != 2 {
+		t.Errorf("Unmarshal FieldUnmarshaler got: %+v", testVal)
+	}
+	if err := ValidateSchema(testVal); err != nil {
+		t.Errorf("ValidateSchema should not check the fields of a FieldUnmarshaler: %v", err)
+	}
+
+	err = Unmarshal([]byte("1234.567"), testVal, 0, 0, false)
+	if err == nil {
+		t.Error("Unmarshal should return the error of a FieldUnmarshaler")
+	}
+}
+
+func TestCents_Unmarshal(t *testing.T) {
+	type Payment struct {
+		Amount  Cents   `flatfile:"1,7"`
+		Fee     *Cents  `flatfile:"8,5"`
+		Refunds []Cents `flatfile:"13,3,2"`
+	}
+
+	testVal := &Payment{}
+	if err := Unmarshal([]byte("0012345 -1.5001-02"), testVal, 0, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	if testVal.Amount != 12345 || testVal.Fee == nil || *testVal.Fee != -150 ||
+		len(testVal.Refunds) != 2 || testVal.Refunds[0] != 1 || testVal.Refunds[1] != -2 {
+		t.Errorf("Unmarshal Cents got: %+v", testVal)
+	}
+	if err := Unmarshal([]byte("123.456"), &Payment{}, 0, 0, false); err == nil {
+		t.Error("Unmarshal should return an error for Cents with more than 2 decimals")
+	}
+
+	var tests = []struct {
+		Cents Cents
+		Want  string
+	}{
+		{12345, "123.45"},
+		{-12345, "-123.45"},
+		{5, "0.05"},
+		{-50, "-0.50"},
+		{0, "0.00"},
+		{math.MinInt64, "-92233720368547758.08"},
+	}
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestCents_String-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			if got := tt.Cents.String(); got != tt.Want {
+				t.Errorf("Cents(%d).String() got: %s want: %s", int64(tt.Cents), got, tt.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package flatfile
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+//utf8BOM is the UTF-8 byte order mark some editors write at the start of a file
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+//Decoder reads and unmarshals records one at a time from a stream so large files are never held in memory
+type Decoder struct {
+	reader *bufio.Reader
+	opts   []Option
+	//recordLen is the length of records without line endings set by UseRecordLength, 0 for lines
+	recordLen int
+	//buf holds a fixed length record and is reused by each call to Decode
+	buf []byte
+	//recordsRead is the number of records read so far, used to strip a byte order mark from the first line only
+	recordsRead int
+	//offset is the number of bytes read so far including line endings
+	offset int64
+	//method names the caller in errors, Decoder.Decode or FlatFile.Read
+	method string
+	//sequence checks a sequence number field across records when set by CheckSequence
+	sequence *sequenceCheck
+}
+
+//sequenceCheck is the state of a sequence number field checked across records
+type sequenceCheck struct {
+	fieldName string
+	//structType is the struct type fieldIdx was found in, so the field is looked up again only if the type changes
+	structType reflect.Type
+	fieldIdx   int
+	next       int64
+	step       int64
+}
+
+//NewDecoder returns a Decoder reading from r. The options are passed to Unmarshal for every record
+func NewDecoder(r io.Reader, opts ...Option) *Decoder {
+	return newDecoder(bufio.NewReader(r), "flatfile.Decoder.Decode", opts...)
+}
+
+//newDecoder returns a Decoder reading from reader that reports errors as coming from method
+func newDecoder(reader *bufio.Reader, method string, opts ...Option) *Decoder {
+	return &Decoder{reader: reader, opts: opts, method: method}
+}
+
+//UseRecordLength makes Decode read records of exactly n bytes with no line endings, as in files of fixed length records
+func (d *Decoder) UseRecordLength(n int) {
+	d.recordLen = n
+}
+
+//Decode reads the next record and unmarshals it into v like Unmarshal, returning io.EOF when there are no more records
+//Records are lines ending in \n or \r\n unless UseRecordLength is set. A UTF-8 byte order mark at the start of the stream is skipped
+//An error reading, unmarshalling or checking the sequence of a record includes its record number and byte offset in the stream to help locate it
+func (d *Decoder) Decode(v interface{}) error {
+	recordOffset := d.offset
+	record, err := d.readRecord()
+	if err != nil {
+		return err
+	}
+	err = Unmarshal(record, v, 0, 0, false, d.opts...)
+	if err == nil && d.sequence != nil {
+		err = d.checkSequence(v)
+	}
+	return errors.Wrapf(err, "%s: Record %d at byte %d", d.method, d.recordsRead, recordOffset)
+}
+
+//CheckSequence makes Decode verify the sequence number field fieldName of each record
+//The first record must hold start and each following record the previous value plus step
+//A gap or duplicate returns an error from Decode. Checking continues from the value read so one bad record reports once
+//The field must be a top level integer field or string field holding a decimal integer of the struct passed to Decode
+func (d *Decoder) CheckSequence(fieldName string, start, step int64) {
+	d.sequence = &sequenceCheck{fieldName: fieldName, next: start, step: step}
+}
+
+//checkSequence compares the sequence number field of the record just decoded into v with the expected value
+func (d *Decoder) checkSequence(v interface{}) error {
+	record := reflect.Indirect(reflect.ValueOf(v))
+	if record.Type() != d.sequence.structType {
+		fieldIdx, err := sequenceField(record.Type(), d.sequence.fieldName)
+		if err != nil {
+			return err
+		}
+		d.sequence.structType, d.sequence.fieldIdx = record.Type(), fieldIdx
+	}
+	got, err := intFieldValue(record.Field(d.sequence.fieldIdx))
+	if err != nil {
+		return errors.Wrapf(err, "Sequence field %s is not a number", d.sequence.fieldName)
+	}
+	want := d.sequence.next
+	d.sequence.next = got + d.sequence.step
+	if got != want {
+		return errors.Errorf("Sequence field %s expected %d but got %d", d.sequence.fieldName, want, got)
+	}
+	return nil
+}
+
+//sequenceField returns the index of the sequence number field fieldName in structType
+func sequenceField(structType reflect.Type, fieldName string) (int, error) {
+	if structType.Kind() != reflect.Struct {
+		return 0, errors.Errorf("%s is not a struct", structType)
+	}
+	structField, exists := structType.FieldByName(fieldName)
+	if !exists || len(structField.Index) != 1 {
+		return 0, errors.Errorf("%s has no field %s", structType, fieldName)
+	}
+	switch structField.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.String:
+	default:
+		return 0, errors.Errorf("Sequence field %s must be an integer or string not %s", fieldName, structField.Type)
+	}
+	return structField.Index[0], nil
+}
+
+//Offset returns the byte offset in the stream of the next record Decode will read
+func (d *Decoder) Offset() int64 {
+	return d.offset
+}
+
+//readRecord reads the bytes of the next record, a line or recordLen bytes
+func (d *Decoder) readRecord() ([]byte, error) {
+	if d.recordLen < 0 {
+		return nil, errors.Errorf("%s: Out of range error. Record length %d cannot be less than 0", d.method, d.recordLen)
+	}
+	if d.recordLen > 0 {
+		if len(d.buf) != d.recordLen {
+			d.buf = make([]byte, d.recordLen)
+		}
+		n, err := io.ReadFull(d.reader, d.buf)
+		d.offset += int64(n)
+		if err == io.EOF {
+			return nil, err
+		}
+		if err == io.ErrUnexpectedEOF {
+			return nil, errors.Errorf("%s: Record %d at byte %d is %d bytes but only %d bytes remain", d.method, d.recordsRead+1, d.offset-int64(n), d.recordLen, n)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: Failed to read record %d at byte %d", d.method, d.recordsRead+1, d.offset-int64(n))
+		}
+		d.recordsRead++
+		return d.buf, nil
+	}
+	line, err := d.reader.ReadBytes('\n')
+	if len(line) == 0 && err == io.EOF {
+		return nil, err
+	}
+	if err != nil && err != io.EOF {
+		return nil, errors.Wrapf(err, "%s: Failed to read record %d at byte %d", d.method, d.recordsRead+1, d.offset)
+	}
+	d.offset += int64(len(line))
+	line = trimLine(bytes.TrimSuffix(line, []byte("\n")), d.recordsRead == 0)
+	d.recordsRead++
+	return line, nil
+}
+
+//trimLine removes the carriage return of a \r\n line ending, or of a file ending in \r, so it is not read as part of the last field
+func trimLine(line []byte, isFirstLine bool) []byte {
+	if isFirstLine {
+		line = bytes.TrimPrefix(line, utf8BOM)
+	}
+	return bytes.TrimSuffix(line, []byte("\r"))
+}
@@ -0,0 +1,98 @@
+package flatfile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	var tests = []struct {
+		Data      string
+		RecordLen int
+		Opts      []Option
+		Want      []testDetail
+		WantErr   string
+	}{
+		{"\xEF\xBB\xBFDAMY0100\r\nDBOB0250\nDCAT0300", 0, nil, []testDetail{{"D", "AMY", 100}, {"D", "BOB", 250}, {"D", "CAT", 300}}, ""},
+		{"DAMY0100DBOB0250", 8, nil, []testDetail{{"D", "AMY", 100}, {"D", "BOB", 250}}, ""},
+		{"DAMY|0100\nDBOB|0250\n", 0, []Option{WithSeparator("|")}, []testDetail{{"D", "AMY", 100}, {"D", "BOB", 250}}, ""},
+		{"DAMY0100\nDBOBXXXX\n", 0, nil, []testDetail{{"D", "AMY", 100}}, "flatfile.Decoder.Decode: Record 2 at byte 9"},
+		{"DAMY0100DBOB", 8, nil, []testDetail{{"D", "AMY", 100}}, "Record 2 at byte 8 is 8 bytes but only 4 bytes remain"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestDecoder-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.Data), tt.Opts...)
+			dec.UseRecordLength(tt.RecordLen)
+			var got []testDetail
+			var err error
+			for {
+				record := testDetail{}
+				if err = dec.Decode(&record); err != nil {
+					break
+				}
+				got = append(got, record)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.Want) {
+				t.Errorf("Decode(%q) got: %v want: %v", tt.Data, got, tt.Want)
+			}
+			if tt.WantErr == "" && err != io.EOF {
+				t.Errorf("Decode(%q) err: %v want EOF", tt.Data, err)
+			}
+			if tt.WantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.WantErr)) {
+				t.Errorf("Decode(%q) err: %v want message containing: %s", tt.Data, err, tt.WantErr)
+			}
+		})
+	}
+}
+
+func TestDecoderOffset(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("DAMY0100\r\nDBOBXXXX\nDCAT0300"))
+	record := testDetail{}
+	if err := dec.Decode(&record); err != nil || dec.Offset() != 10 {
+		t.Fatalf("Decode got offset: %d err: %v want offset: 10", dec.Offset(), err)
+	}
+	err := dec.Decode(&record)
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "Amount" {
+		t.Errorf("Decode err: %v should wrap a FieldError for Amount", err)
+	}
+	//decoding continues after a record that fails to unmarshal
+	if err := dec.Decode(&record); err != nil || record.Name != "CAT" || dec.Offset() != 27 {
+		t.Errorf("Decode got: %+v offset: %d err: %v", record, dec.Offset(), err)
+	}
+}
+
+func TestDecoderCheckSequence(t *testing.T) {
+	type seqType struct {
+		Seq  string `flatfile:"1,2"`
+		Data string `flatfile:"3,2"`
+	}
+
+	dec := NewDecoder(strings.NewReader("01AA02BB02CC04DD05EE"))
+	dec.UseRecordLength(4)
+	dec.CheckSequence("Seq", 1, 1)
+	wantErrs := []string{"", "", "Record 3 at byte 8: Sequence field Seq expected 3 but got 2", "Record 4 at byte 12: Sequence field Seq expected 3 but got 4", ""}
+	for i, wantErr := range wantErrs {
+		err := dec.Decode(&seqType{})
+		if wantErr == "" && err != nil {
+			t.Errorf("Decode() record %d unexpected error %v", i+1, err)
+		}
+		if wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr)) {
+			t.Errorf("Decode() record %d err: %v want message containing: %s", i+1, err, wantErr)
+		}
+	}
+	if err := dec.Decode(&seqType{}); err != io.EOF {
+		t.Errorf("Decode() got %v want EOF", err)
+	}
+
+	dec = NewDecoder(strings.NewReader("01AA\n"))
+	dec.CheckSequence("Missing", 1, 1)
+	if err := dec.Decode(&seqType{}); err == nil || !strings.Contains(err.Error(), "Record 1 at byte 0: flatfile.seqType has no field Missing") {
+		t.Errorf("Decode() err: %v want an error for the missing sequence field", err)
+	}
+}
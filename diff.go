@@ -0,0 +1,57 @@
+package flatfile
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+//FieldDiff is a field whose value differs between two records
+type FieldDiff struct {
+	//Field is the name of the struct field. Fields of nested structs are named Outer.Inner
+	Field string
+	//A and B are the values of the field in each record
+	A interface{}
+	B interface{}
+}
+
+//DiffRecords unmarshals records a and b into new values of the struct type v points to and returns the tagged fields that differ
+//v itself is not modified. Fields are reported in struct order
+func DiffRecords(a, b []byte, v interface{}) ([]FieldDiff, error) {
+	vType := reflect.TypeOf(v)
+	if vType == nil || vType.Kind() != reflect.Ptr || vType.Elem().Kind() != reflect.Struct {
+		return nil, errors.Errorf("flatfile.DiffRecords: %v is not a pointer to a struct", vType)
+	}
+	recA := reflect.New(vType.Elem())
+	if err := Unmarshal(a, recA.Interface(), 0, 0, false); err != nil {
+		return nil, errors.Wrap(err, "flatfile.DiffRecords: Failed to unmarshal record a")
+	}
+	recB := reflect.New(vType.Elem())
+	if err := Unmarshal(b, recB.Interface(), 0, 0, false); err != nil {
+		return nil, errors.Wrap(err, "flatfile.DiffRecords: Failed to unmarshal record b")
+	}
+	return diffStruct(recA.Elem(), recB.Elem(), "", nil), nil
+}
+
+//diffStruct appends the differing tagged fields of structs a and b to diffs, prefixing field names with path
+func diffStruct(a, b reflect.Value, path string, diffs []FieldDiff) []FieldDiff {
+	layout := cachedStructLayout(a.Type())
+	for i := range layout.fields {
+		if !layout.fields[i].tagged {
+			continue
+		}
+		name := path + a.Type().Field(i).Name
+		fieldA, fieldB := a.Field(i), b.Field(i)
+		if fieldA.Kind() == reflect.Ptr && !fieldA.IsNil() && !fieldB.IsNil() {
+			fieldA, fieldB = fieldA.Elem(), fieldB.Elem()
+		}
+		if layout.fields[i].tag.conv == "" && isNestedStruct(fieldA.Type()) {
+			diffs = diffStruct(fieldA, fieldB, name+".", diffs)
+			continue
+		}
+		if !reflect.DeepEqual(fieldA.Interface(), fieldB.Interface()) {
+			diffs = append(diffs, FieldDiff{Field: name, A: fieldA.Interface(), B: fieldB.Interface()})
+		}
+	}
+	return diffs
+}
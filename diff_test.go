@@ -0,0 +1,47 @@
+package flatfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffRecords(t *testing.T) {
+	type Address struct {
+		City string `flatfile:"1,5"`
+		Zip  int    `flatfile:"6,3"`
+	}
+	type Customer struct {
+		Name    string   `flatfile:"1,5"`
+		Balance int      `flatfile:"6,4"`
+		Codes   []string `flatfile:"10,1,3"`
+		Home    Address  `flatfile:"13,8"`
+		Notes   string
+	}
+
+	a := []byte("AMY  0100ABCParis123")
+	b := []byte("AMY  0250ABDRome 123")
+	diffs, err := DiffRecords(a, b, &Customer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FieldDiff{
+		{Field: "Balance", A: 100, B: 250},
+		{Field: "Codes", A: []string{"A", "B", "C"}, B: []string{"A", "B", "D"}},
+		{Field: "Home.City", A: "Paris", B: "Rome "},
+	}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("DiffRecords(%s,%s) got: %v want: %v", a, b, diffs, want)
+	}
+
+	diffs, err = DiffRecords(a, a, &Customer{})
+	if err != nil || len(diffs) != 0 {
+		t.Errorf("DiffRecords of the same record got: %v err: %v", diffs, err)
+	}
+
+	if _, err = DiffRecords(a, []byte("AMY  XXXX"), &Customer{}); err == nil {
+		t.Error("DiffRecords should return an error when a record fails to unmarshal")
+	}
+	if _, err = DiffRecords(a, b, Customer{}); err == nil {
+		t.Error("DiffRecords should return an error when v is not a pointer")
+	}
+}
@@ -0,0 +1,58 @@
+package flatfile
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+//Encoder marshals and writes records one at a time to a stream so a large file is never buffered in memory
+type Encoder struct {
+	writer io.Writer
+	opts   []Option
+	//terminator is written after each record, \n unless changed with SetTerminator
+	terminator string
+	//blockSize and blockFill pad each record to a multiple of blockSize bytes when set by SetBlockSize, 0 for no padding
+	blockSize int
+	blockFill byte
+	//recordsWritten is the number of records written so far, used to identify a record that fails
+	recordsWritten int
+}
+
+//NewEncoder returns an Encoder writing to w with each record ending in \n. The options are passed to Marshal for every record
+func NewEncoder(w io.Writer, opts ...Option) *Encoder {
+	return &Encoder{writer: w, opts: opts, terminator: "\n"}
+}
+
+//SetTerminator sets what is written after each record e.g. \r\n for a Windows file, or "" for fixed length records with no line endings
+func (e *Encoder) SetTerminator(terminator string) {
+	e.terminator = terminator
+}
+
+//SetBlockSize pads each record with fill to the next multiple of size bytes before the terminator, as in card and tape images
+//e.g. SetBlockSize(80, ' ') writes a 30 byte record as 80 bytes and a 100 byte record as 160. A size of 0 turns padding off
+//fill is written as is, so give the space of the encoding e.g. 0x40 for an EBCDIC space
+func (e *Encoder) SetBlockSize(size int, fill byte) {
+	e.blockSize, e.blockFill = size, fill
+}
+
+//Encode marshals v like Marshal and writes the record followed by the terminator in a single write
+//An error marshalling v includes its record number and nothing is written for it
+func (e *Encoder) Encode(v interface{}) error {
+	if e.blockSize < 0 {
+		return errors.Errorf("flatfile.Encoder.Encode: Out of range error. Block size %d cannot be less than 0", e.blockSize)
+	}
+	record, err := Marshal(v, e.opts...)
+	if err != nil {
+		return errors.Wrapf(err, "flatfile.Encoder.Encode: Record %d", e.recordsWritten+1)
+	}
+	if e.blockSize > 0 && len(record)%e.blockSize != 0 {
+		record = append(record, bytes.Repeat([]byte{e.blockFill}, e.blockSize-len(record)%e.blockSize)...)
+	}
+	if _, err := e.writer.Write(append(record, e.terminator...)); err != nil {
+		return errors.Wrapf(err, "flatfile.Encoder.Encode: Failed to write record %d", e.recordsWritten+1)
+	}
+	e.recordsWritten++
+	return nil
+}
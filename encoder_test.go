@@ -0,0 +1,137 @@
+package flatfile
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEncoder(t *testing.T) {
+	records := []testDetail{{"D", "AMY", 100}, {"D", "BOB", 250}}
+
+	var tests = []struct {
+		SetTerminator bool
+		Terminator    string
+		Want          string
+	}{
+		{false, "", "DAMY 100\nDBOB 250\n"},
+		{true, "\r\n", "DAMY 100\r\nDBOB 250\r\n"},
+		{true, "", "DAMY 100DBOB 250"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestEncoder-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			var out bytes.Buffer
+			enc := NewEncoder(&out)
+			if tt.SetTerminator {
+				enc.SetTerminator(tt.Terminator)
+			}
+			for _, record := range records {
+				if err := enc.Encode(&record); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if out.String() != tt.Want {
+				t.Errorf("Encode got: %q want: %q", out.String(), tt.Want)
+			}
+		})
+	}
+
+	//records written by an Encoder are read back by a Decoder
+	var out bytes.Buffer
+	enc := NewEncoder(&out)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dec := NewDecoder(&out)
+	for _, want := range records {
+		got := testDetail{}
+		if err := dec.Decode(&got); err != nil || got != want {
+			t.Errorf("Decode got: %+v err: %v want: %+v", got, err, want)
+		}
+	}
+}
+
+func TestEncoderOverflowPolicy(t *testing.T) {
+	var out bytes.Buffer
+	enc := NewEncoder(&out, WithOverflowPolicy(OverflowTruncateLeft))
+	if err := enc.Encode(&testDetail{"D", "AMY", 12345}); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "DAMY2345\n" {
+		t.Errorf("Encode got: %q want: %q", out.String(), "DAMY2345\n")
+	}
+}
+
+func TestEncoderZeroPad(t *testing.T) {
+	var out bytes.Buffer
+	enc := NewEncoder(&out, WithZeroPad())
+	if err := enc.Encode(&testDetail{"D", "AMY", -12}); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "DAMY-012\n" {
+		t.Errorf("Encode got: %q want: %q", out.String(), "DAMY-012\n")
+	}
+}
+
+func TestEncoderBlockSize(t *testing.T) {
+	records := []testDetail{{"D", "AMY", 100}, {"D", "BOB", 250}}
+
+	var tests = []struct {
+		BlockSize  int
+		Fill       byte
+		Terminator string
+		Want       string
+		WantErr    string
+	}{
+		{5, '*', "\n", "DAMY 100**\nDBOB 250**\n", ""},
+		{4, '*', "\n", "DAMY 100\nDBOB 250\n", ""},
+		{12, ' ', "", "DAMY 100    DBOB 250    ", ""},
+		{0, '*', "", "DAMY 100DBOB 250", ""},
+		{-1, ' ', "", "", "Block size -1 cannot be less than 0"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestEncoderBlockSize-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			var out bytes.Buffer
+			enc := NewEncoder(&out)
+			enc.SetTerminator(tt.Terminator)
+			enc.SetBlockSize(tt.BlockSize, tt.Fill)
+			var err error
+			for _, record := range records {
+				if err = enc.Encode(&record); err != nil {
+					break
+				}
+			}
+			if out.String() != tt.Want {
+				t.Errorf("Encode got: %q want: %q", out.String(), tt.Want)
+			}
+			if tt.WantErr == "" && err != nil {
+				t.Errorf("Encode unexpected error %v", err)
+			}
+			if tt.WantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.WantErr)) {
+				t.Errorf("Encode err: %v want message containing: %s", err, tt.WantErr)
+			}
+		})
+	}
+}
+
+func TestEncoderErr(t *testing.T) {
+	var out bytes.Buffer
+	enc := NewEncoder(&out)
+	if err := enc.Encode(testDetail{"D", "AMY", 100}); err != nil {
+		t.Fatal(err)
+	}
+	err := enc.Encode(testDetail{"D", "AMELIA", 100})
+	if err == nil || !strings.Contains(err.Error(), "flatfile.Encoder.Encode: Record 2") {
+		t.Errorf("Encode err: %v want message containing: flatfile.Encoder.Encode: Record 2", err)
+	}
+	if out.String() != "DAMY 100\n" {
+		t.Errorf("Encode should not write a record that fails to marshal got: %q", out.String())
+	}
+}
@@ -0,0 +1,191 @@
+package flatfile
+
+import (
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+//Encoding is a single byte character encoding used to decode field data to UTF-8 before it is assigned, and encode it when marshalled
+type Encoding struct {
+	name  string
+	table [256]rune
+	//bytes holds the byte of each rune in table for encoding
+	bytes map[rune]byte
+	//ascii is true when the bytes 0x00 to 0x7F decode to themselves, as in Latin1 but not EBCDIC
+	ascii bool
+}
+
+//String returns the name of the encoding
+func (e *Encoding) String() string {
+	return e.name
+}
+
+//decode translates each byte of data to its rune in the encoding and returns the UTF-8 bytes
+func (e *Encoding) decode(data []byte) []byte {
+	decoded := make([]byte, 0, len(data))
+	buf := make([]byte, utf8.UTFMax)
+	for _, b := range data {
+		n := utf8.EncodeRune(buf, e.table[b])
+		decoded = append(decoded, buf[:n]...)
+	}
+	return decoded
+}
+
+//encode translates the UTF-8 runes of data to their bytes in the encoding
+//A rune the encoding has no byte for is an error
+func (e *Encoding) encode(data []byte) ([]byte, error) {
+	encoded := make([]byte, 0, len(data))
+	for _, r := range string(data) {
+		b, ok := e.bytes[r]
+		if !ok {
+			return nil, errors.Errorf("flatfile.Encoding.encode: %q has no byte in %s", r, e.name)
+		}
+		encoded = append(encoded, b)
+	}
+	return encoded, nil
+}
+
+//space returns the byte of a space in the encoding, used to pad marshalled records
+func (e *Encoding) space() byte {
+	return e.bytes[' ']
+}
+
+//Latin1 is ISO-8859-1 where every byte maps to the unicode code point of the same value
+var Latin1 = newEncoding("ISO-8859-1", nil)
+
+//Windows1252 is Latin1 with printable characters in place of the C1 control codes 0x80 to 0x9F
+//Bytes left undefined by Windows-1252 decode the same as Latin1
+var Windows1252 = newEncoding("Windows-1252", map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+	0x88: 'ˆ', 0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž',
+	0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+	0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›', 0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+})
+
+//newEncoding builds an Encoding starting from Latin1 and replacing the runes for the bytes in overrides
+func newEncoding(name string, overrides map[byte]rune) *Encoding {
+	var table [256]rune
+	for i := range table {
+		table[i] = rune(i)
+	}
+	return newTableEncoding(name, table, overrides)
+}
+
+//newTableEncoding builds an Encoding from table replacing the runes for the bytes in overrides
+func newTableEncoding(name string, table [256]rune, overrides map[byte]rune) *Encoding {
+	e := &Encoding{name: name, table: table, bytes: make(map[rune]byte, len(table)), ascii: true}
+	for b, r := range overrides {
+		e.table[b] = r
+	}
+	for i, r := range e.table {
+		if i < utf8.RuneSelf && r != rune(i) {
+			e.ascii = false
+		}
+		//the first byte of a rune is kept should an encoding map two bytes to it
+		if _, exists := e.bytes[r]; !exists {
+			e.bytes[r] = byte(i)
+		}
+	}
+	return e
+}
+
+//isTextField returns true for fields of type t holding text, decoded in full by encodings that do not share ASCII such as EBCDIC
+//Fields read as raw bytes or handed their data as is, and fields made of other fields, are not
+func isTextField(t reflect.Type, ffpTag *flatfileTag) bool {
+	if ffpTag.keepRaw || ffpTag.bitFlags || ffpTag.zoned || ffpTag.packed || ffpTag.override == "byte" || ffpTag.conv != "" {
+		return false
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == fieldCallbackType || implementsFieldUnmarshaler(t) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return t == timeType || isSQLNullType(t)
+	case reflect.Array, reflect.Slice:
+		return !isRepeating(t.Kind(), ffpTag)
+	}
+	return true
+}
+
+//textData returns fieldData decoded when the encoding does not share ASCII, for comparing it to text from a tag such as const
+func (o *unmarshalOptions) textData(fieldData []byte) []byte {
+	if o.encoding != nil && !o.encoding.ascii {
+		return o.encoding.decode(fieldData)
+	}
+	return fieldData
+}
+
+//textRecord returns record translated byte for byte to ASCII when enc does not share ASCII, so the conditions of tags can be checked against it
+//Columns are kept in place so a byte whose character is not ASCII becomes 0x1A
+func textRecord(record []byte, enc *Encoding) []byte {
+	if enc == nil || enc.ascii {
+		return record
+	}
+	text := make([]byte, len(record))
+	for i, b := range record {
+		text[i] = 0x1A
+		if r := enc.table[b]; r < utf8.RuneSelf {
+			text[i] = byte(r)
+		}
+	}
+	return text
+}
+
+//WithEncoding decodes the data of string fields from enc to UTF-8 before assignment
+//An encoding that does not share ASCII, such as CP037, decodes numbers, bools, times and enums as well as strings
+//Marshal encodes the same fields to enc and pads the record with the space of enc
+func WithEncoding(enc *Encoding) Option {
+	return func(o *unmarshalOptions) {
+		o.encoding = enc
+	}
+}
+
+//WithNormalize applies normalize to the text of every string field after it is decoded and before it is trimmed
+//e.g. WithNormalize(strings.ToUpper) to fold the case of EBCDIC data, or a function mapping national use characters to ASCII
+//Fields tagged keepraw are not normalized
+func WithNormalize(normalize func(string) string) Option {
+	return func(o *unmarshalOptions) {
+		o.normalize = normalize
+	}
+}
+
+//WithEncodingField decodes the string fields of each record with the encoding selected by the value of the field named fieldName
+//e.g. WithEncodingField("Charset", map[string]*Encoding{"A": nil, "W": Windows1252}) for files whose records state their own encoding
+//The selecting field is read first, as is and ignoring surrounding whitespace, and is itself assigned without the selected encoding
+//A nil Encoding leaves fields undecoded and a value missing from encodings is an error
+//It applies to the struct passed to Unmarshal, or each record of a slice, and the structs nested in it
+func WithEncodingField(fieldName string, encodings map[string]*Encoding) Option {
+	return func(o *unmarshalOptions) {
+		o.encodingField = fieldName
+		o.encodings = encodings
+	}
+}
+
+//selectEncoding returns a copy of the options using the encoding selected by the encoding field of the record in data
+//The index of the encoding field in struct type t is also returned
+func (o *unmarshalOptions) selectEncoding(data []byte, colOffset int, t reflect.Type, layout *structLayout) (*unmarshalOptions, int, error) {
+	structField, exists := t.FieldByName(o.encodingField)
+	if !exists || len(structField.Index) != 1 || !layout.fields[structField.Index[0]].tagged {
+		return nil, -1, errors.Errorf("flatfile.WithEncodingField: %s has no tagged field %s", t, o.encodingField)
+	}
+	fieldIdx := structField.Index[0]
+	fieldData, err := fieldRefData(data, colOffset, &layout.fields[fieldIdx])
+	if err != nil {
+		return nil, -1, errors.Wrapf(err, "flatfile.WithEncodingField: Encoding field %s", o.encodingField)
+	}
+	code := strings.TrimSpace(string(fieldData))
+	enc, known := o.encodings[code]
+	if !known {
+		return nil, -1, errors.Errorf("flatfile.WithEncodingField: Encoding field %s has unknown value %q", o.encodingField, code)
+	}
+	recordOpts := *o
+	recordOpts.encoding = enc
+	recordOpts.encodingField = ""
+	return &recordOpts, fieldIdx, nil
+}
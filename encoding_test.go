@@ -0,0 +1,175 @@
+package flatfile
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWithEncoding_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Name  string `flatfile:"1,4"`
+		Price string `flatfile:"5,2"`
+	}
+
+	var tests = []struct {
+		Opts []Option
+		Want FfpTest
+	}{
+		{[]Option{WithEncoding(Latin1)}, FfpTest{Name: "José", Price: "\u00805"}},
+		{[]Option{WithEncoding(Windows1252)}, FfpTest{Name: "José", Price: "€5"}},
+		{nil, FfpTest{Name: "Jos\xe9", Price: "\x805"}},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestWithEncoding_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := FfpTest{}
+			err := Unmarshal([]byte("Jos\xe9\x805"), &got, 0, 0, false, tt.Opts...)
+			if err != nil {
+				t.Errorf("err: %s", err)
+			}
+			if got.Name != tt.Want.Name || got.Price != tt.Want.Price {
+				t.Errorf("Unmarshal got: %q want: %q", got, tt.Want)
+			}
+		})
+	}
+}
+
+func TestWithEncodingField_Unmarshal(t *testing.T) {
+	type Price struct {
+		Amount string `flatfile:"1,2"`
+	}
+	type FfpTest struct {
+		Name    string `flatfile:"2,4"`
+		Price   Price  `flatfile:"6,2"`
+		Charset string `flatfile:"1,1"`
+	}
+	encodings := map[string]*Encoding{"A": nil, "L": Latin1, "W": Windows1252}
+
+	var tests = []struct {
+		Data    string
+		Want    FfpTest
+		WantErr string
+	}{
+		{"WJos\xe9\x805", FfpTest{Name: "José", Price: Price{"€5"}, Charset: "W"}, ""},
+		{"LJos\xe9\x805", FfpTest{Name: "José", Price: Price{"\u00805"}, Charset: "L"}, ""},
+		{"AJos\xe9\x805", FfpTest{Name: "Jos\xe9", Price: Price{"\x805"}, Charset: "A"}, ""},
+		{"EJos\xe9\x805", FfpTest{}, "Encoding field Charset has unknown value \"E\""},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestWithEncodingField_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := FfpTest{}
+			err := Unmarshal([]byte(tt.Data), &got, 0, 0, false, WithEncodingField("Charset", encodings))
+			if tt.WantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.WantErr) {
+					t.Errorf("Unmarshal(%q) err: %v want message containing: %s", tt.Data, err, tt.WantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.Want {
+				t.Errorf("Unmarshal(%q) got: %q err: %v want: %q", tt.Data, got, err, tt.Want)
+			}
+		})
+	}
+
+	var records []FfpTest
+	err := Unmarshal([]byte("WJos\xe9\x805AJos\xe9\x805"), &records, 0, 0, false, WithEncodingField("Charset", encodings))
+	if err != nil || len(records) != 2 || records[0].Name != "José" || records[1].Name != "Jos\xe9" {
+		t.Errorf("Unmarshal records should select the encoding of each record got: %q err: %v", records, err)
+	}
+	if err := Unmarshal([]byte("WJose"), &FfpTest{}, 0, 0, false, WithEncodingField("Missing", encodings)); err == nil {
+		t.Error("Unmarshal should return an error for a missing encoding field")
+	}
+}
+
+type testEBCDIC struct {
+	Name   string `flatfile:"1,5"`
+	Count  int    `flatfile:"6,3"`
+	Active bool   `flatfile:"9,1"`
+	Spare  *int   `flatfile:"10,2"`
+	Code   string `flatfile:"12,1"`
+}
+
+func TestEBCDIC(t *testing.T) {
+	want := testEBCDIC{Name: "HELLO", Count: -42, Active: true, Code: "["}
+
+	var tests = []struct {
+		Enc  *Encoding
+		Data string
+	}{
+		{CP037, "\xC8\xC5\xD3\xD3\xD6\x60\xF4\xF2\xE3\x40\x40\xBA"},
+		{CP1047, "\xC8\xC5\xD3\xD3\xD6\x60\xF4\xF2\xE3\x40\x40\xAD"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestEBCDIC-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := testEBCDIC{}
+			if err := Unmarshal([]byte(tt.Data), &got, 0, 0, false, WithEncoding(tt.Enc)); err != nil || got != want {
+				t.Errorf("Unmarshal(%q) got: %+v err: %v want: %+v", tt.Data, got, err, want)
+			}
+			record, err := Marshal(want, WithEncoding(tt.Enc))
+			if err != nil || string(record) != tt.Data {
+				t.Errorf("Marshal(%+v) got: %q err: %v want: %q", want, record, err, tt.Data)
+			}
+			//z/OS datasets are fixed length records with no line endings
+			dec := NewDecoder(strings.NewReader(tt.Data+tt.Data), WithEncoding(tt.Enc))
+			dec.UseRecordLength(len(tt.Data))
+			for i := 0; i < 2; i++ {
+				if err := dec.Decode(&got); err != nil || got != want {
+					t.Errorf("Decode record %d got: %+v err: %v want: %+v", i+1, got, err, want)
+				}
+			}
+		})
+	}
+
+	//strings alone are encoded by encodings that share ASCII
+	if record, err := Marshal(testEBCDIC{Name: "José", Count: 1}, WithEncoding(Latin1)); err != nil || string(record) != "Jos\xe9   1F   " {
+		t.Errorf("Marshal with Latin1 got: %q err: %v", record, err)
+	}
+	if _, err := Marshal(testEBCDIC{Name: "€5"}, WithEncoding(CP037)); err == nil || !strings.Contains(err.Error(), "'€' has no byte in IBM037") {
+		t.Errorf("Marshal err: %v want message containing: '€' has no byte in IBM037", err)
+	}
+}
+
+func TestWithNormalize_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Name  string `flatfile:"1,5"`
+		Code  string `flatfile:"6,4,,trim"`
+		Raw   string `flatfile:"10,3,,keepraw"`
+		Count int    `flatfile:"13,2"`
+	}
+	//amy, x and ab padded with the EBCDIC space 0x40
+	data := "\x81\x94\xA8\x40\x40\x40\xA7\x40\x40\x81\x82\x40\xF4\xF2"
+
+	var tests = []struct {
+		Opts []Option
+		Want FfpTest
+	}{
+		//0x40 decodes to an ASCII space so trim removes it
+		{[]Option{WithEncoding(CP037)}, FfpTest{"amy  ", "x", "\x81\x82\x40", 42}},
+		{[]Option{WithEncoding(CP037), WithNormalize(strings.ToUpper)}, FfpTest{"AMY  ", "X", "\x81\x82\x40", 42}},
+		//normalizing runs before trimming
+		{[]Option{WithEncoding(CP037), WithNormalize(func(s string) string { return strings.Replace(s, "x", " ", -1) })}, FfpTest{"amy  ", "", "\x81\x82\x40", 42}},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestWithNormalize_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := FfpTest{}
+			if err := Unmarshal([]byte(data), &got, 0, 0, false, tt.Opts...); err != nil || got != tt.Want {
+				t.Errorf("Unmarshal(%q) got: %+v err: %v want: %+v", data, got, err, tt.Want)
+			}
+		})
+	}
+
+	if decoded := string(CP037.decode([]byte{0x40, 0xC1, 0x40})); decoded != " A " {
+		t.Errorf("CP037 decode of 0x40 got: %q want: %q", decoded, " A ")
+	}
+	if _, err := Marshal(FfpTest{}, WithNormalize(strings.ToUpper)); err == nil || !strings.Contains(err.Error(), "WithNormalize only applies to Unmarshal") {
+		t.Errorf("Marshal err: %v want message containing: WithNormalize only applies to Unmarshal", err)
+	}
+}
@@ -0,0 +1,73 @@
+package flatfile
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+//enumRegistry maps a field type to the values its codes translate to
+var enumRegistry = struct {
+	sync.RWMutex
+	enums map[reflect.Type]map[string]reflect.Value
+}{enums: make(map[reflect.Type]map[string]reflect.Value)}
+
+//enumsRegistered is set once any enum is registered so fields are not slowed by locking the registry when there are none
+var enumsRegistered int32
+
+//RegisterEnum maps the codes found in a field to typed constants of t
+//Any field of type t is unmarshalled by looking up its data, with surrounding whitespace removed, in codes
+//Data that does not match a code is an error
+//For example:
+//type Status int
+//const (
+//		StatusActive Status = iota
+//		StatusClosed
+//)
+//flatfile.RegisterEnum(reflect.TypeOf(Status(0)), map[string]interface{}{"A": StatusActive, "C": StatusClosed})
+func RegisterEnum(t reflect.Type, codes map[string]interface{}) error {
+	values := make(map[string]reflect.Value, len(codes))
+	for code, value := range codes {
+		v := reflect.ValueOf(value)
+		if !v.IsValid() || !v.Type().ConvertibleTo(t) {
+			return errors.Errorf("flatfile.RegisterEnum: Value %v for code %s cannot be converted to %s", value, code, t)
+		}
+		values[strings.TrimSpace(code)] = v.Convert(t)
+	}
+
+	enumRegistry.Lock()
+	defer enumRegistry.Unlock()
+	enumRegistry.enums[t] = values
+	atomic.StoreInt32(&enumsRegistered, 1)
+	return nil
+}
+
+//lookupEnum returns the registered codes for t
+func lookupEnum(t reflect.Type) (map[string]reflect.Value, bool) {
+	if atomic.LoadInt32(&enumsRegistered) == 0 {
+		return nil, false
+	}
+	enumRegistry.RLock()
+	defer enumRegistry.RUnlock()
+	values, ok := enumRegistry.enums[t]
+	return values, ok
+}
+
+func assignEnum(field reflect.Value, fieldData []byte, codes map[string]reflect.Value) error {
+	code := strings.TrimSpace(string(fieldData))
+	value, ok := codes[code]
+	if !ok {
+		validCodes := make([]string, 0, len(codes))
+		for validCode := range codes {
+			validCodes = append(validCodes, validCode)
+		}
+		sort.Strings(validCodes)
+		return errors.Errorf("flatfile.assignEnum: Invalid code %q for %s. Valid codes: %v", code, field.Type(), validCodes)
+	}
+	field.Set(value)
+	return nil
+}
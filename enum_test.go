@@ -0,0 +1,74 @@
+package flatfile
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testStatus int
+
+const (
+	testStatusActive testStatus = iota + 1
+	testStatusClosed
+)
+
+type testCurrency string
+
+func TestRegisterEnum_Unmarshal(t *testing.T) {
+	if err := RegisterEnum(reflect.TypeOf(testStatus(0)), map[string]interface{}{"A": testStatusActive, "C": testStatusClosed}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterEnum(reflect.TypeOf(testCurrency("")), map[string]interface{}{"1": "CAD", "2": "USD"}); err != nil {
+		t.Fatal(err)
+	}
+
+	type FfpTest struct {
+		Status   testStatus    `flatfile:"1,2"`
+		Currency *testCurrency `flatfile:"3,1"`
+		History  []testStatus  `flatfile:"4,1,2"`
+	}
+
+	var tests = []struct {
+		Record     []byte
+		WantStatus testStatus
+		WantCcy    testCurrency
+		WantHist   []testStatus
+		WantErr    string
+	}{
+		{[]byte("A 2CA"), testStatusActive, "USD", []testStatus{testStatusClosed, testStatusActive}, ""},
+		{[]byte(" C1AA"), testStatusClosed, "CAD", []testStatus{testStatusActive, testStatusActive}, ""},
+		{[]byte("X 1AA"), 0, "", nil, "Valid codes: [A C]"},
+		{[]byte("A 1AX"), 0, "", nil, "Valid codes: [A C]"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestRegisterEnum_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			var ccy testCurrency
+			got := FfpTest{Currency: &ccy}
+			err := Unmarshal(tt.Record, &got, 0, 0, false)
+			if tt.WantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.WantErr) {
+					t.Errorf("Unmarshal(%s) err: %v want: %s", string(tt.Record), err, tt.WantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Status != tt.WantStatus || *got.Currency != tt.WantCcy || !reflect.DeepEqual(got.History, tt.WantHist) {
+				t.Errorf("Unmarshal(%s) got: %v %v %v want: %v %v %v", string(tt.Record), got.Status, *got.Currency, got.History, tt.WantStatus, tt.WantCcy, tt.WantHist)
+			}
+		})
+	}
+}
+
+func TestRegisterEnumErr(t *testing.T) {
+	err := RegisterEnum(reflect.TypeOf(testStatus(0)), map[string]interface{}{"A": "not a status"})
+	if err == nil {
+		t.Error("RegisterEnum should return an error when a value cannot be converted")
+	}
+	t.Log(err)
+}
@@ -0,0 +1,50 @@
+package flatfile
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+//FieldError is returned by Unmarshal when a single field of a record fails to unmarshal
+//It can be extracted with errors.As to log the failing field in a structured form
+type FieldError struct {
+	//Field is the name of the struct field that failed
+	Field string
+	//Col is the 1-indexed column of the field in the record
+	Col int
+	//Length is the length of the field in the record
+	Length int
+	//Value is the raw data of the field
+	Value string
+	//Err is the underlying cause
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("flatfile.Unmarshal: Failed to unmarshal field %s col %d len %d value %q: %v", e.Field, e.Col, e.Length, e.Value, e.Err)
+}
+
+//Unwrap returns the underlying cause for use with errors.Is and errors.As
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+//Cause returns the underlying cause for use with github.com/pkg/errors
+func (e *FieldError) Cause() error {
+	return e.Err
+}
+
+//MarshalJSON produces a flat JSON object of the field details and the cause message
+func (e *FieldError) MarshalJSON() ([]byte, error) {
+	cause := ""
+	if e.Err != nil {
+		cause = e.Err.Error()
+	}
+	return json.Marshal(struct {
+		Field  string `json:"field"`
+		Col    int    `json:"col"`
+		Length int    `json:"length"`
+		Value  string `json:"value"`
+		Cause  string `json:"cause"`
+	}{e.Field, e.Col, e.Length, e.Value, cause})
+}
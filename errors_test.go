@@ -0,0 +1,43 @@
+package flatfile
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestFieldError_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Name string `flatfile:"1,3"`
+		Age  int    `flatfile:"4,2"`
+	}
+
+	err := Unmarshal([]byte("AMYXY"), &FfpTest{}, 0, 0, false)
+
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) {
+		t.Fatalf("Unmarshal should return a FieldError got: %v", err)
+	}
+	if fieldErr.Field != "Age" || fieldErr.Col != 4 || fieldErr.Length != 2 || fieldErr.Value != "XY" {
+		t.Errorf("Unexpected FieldError %+v", fieldErr)
+	}
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) {
+		t.Errorf("FieldError should unwrap to the strconv error got: %v", err)
+	}
+}
+
+func TestFieldError_MarshalJSON(t *testing.T) {
+	fieldErr := &FieldError{Field: "Age", Col: 4, Length: 2, Value: "XY", Err: errors.New("bad\nvalue")}
+
+	data, err := json.Marshal(fieldErr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"field":"Age","col":4,"length":2,"value":"XY","cause":"bad\nvalue"}`
+	if string(data) != want {
+		t.Errorf("json.Marshal(FieldError) got: %s want: %s", data, want)
+	}
+}
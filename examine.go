@@ -2,8 +2,12 @@ package flatfile
 
 import (
 	"fmt"
+	"io"
 	"reflect"
 	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
 )
 
 // Examine traverses all elements of a type and uses the reflect pkg to print type and kind
@@ -29,3 +33,73 @@ func examiner(t reflect.Type, depth int) {
 		}
 	}
 }
+
+//Dump writes a table of the tagged fields of v, a struct or pointer to a struct, with their column, length and current value
+//Call it after Unmarshal to check that columns landed where expected. It is meant for reading, not parsing
+//Fields are listed in column order and the fields of nested structs with their column in the record
+//String values are quoted so padding is visible
+func Dump(w io.Writer, v interface{}) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return errors.Errorf("flatfile.Dump: Expected a struct or pointer to a struct but got %v", reflect.TypeOf(v))
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tCOL\tLEN\tVALUE")
+	dumpStruct(tw, value, "", 0)
+	return errors.Wrap(tw.Flush(), "flatfile.Dump: Failed to write table")
+}
+
+//dumpStruct writes a row for each tagged field of vStruct, prefixing field names with path and offsetting columns by colOffset
+func dumpStruct(w io.Writer, vStruct reflect.Value, path string, colOffset int) {
+	layout := cachedStructLayout(vStruct.Type())
+	for _, i := range layout.byCol {
+		field := &layout.fields[i]
+		name := path + vStruct.Type().Field(i).Name
+		if field.err != nil {
+			fmt.Fprintf(w, "%s\t\t\tinvalid tag %s\n", name, field.rawTag)
+			continue
+		}
+		col := colOffset + field.tag.col
+		nested := vStruct.Field(i)
+		if nested.Kind() == reflect.Ptr && !nested.IsNil() {
+			nested = nested.Elem()
+		}
+		if field.tag.conv == "" && isNestedStruct(nested.Type()) {
+			dumpStruct(w, nested, name+".", col-1)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", name, col, dumpWidth(vStruct.Field(i), &field.tag), dumpValue(vStruct.Field(i)))
+	}
+}
+
+//dumpWidth returns the bytes field spans, counting the elements actually read for a variable length slice
+func dumpWidth(field reflect.Value, ffpTag *flatfileTag) int {
+	if field.Kind() == reflect.Ptr && !field.IsNil() {
+		field = field.Elem()
+	}
+	if field.Kind() == reflect.Slice && (ffpTag.occurs == greedyOccurs || ffpTag.occursCol > 0) {
+		return field.Len() * elementWidth(field.Type().Elem(), ffpTag)
+	}
+	return fieldWidth(field.Type(), ffpTag)
+}
+
+//dumpValue formats the value of field, quoting strings and following pointers
+func dumpValue(field reflect.Value) string {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return "<nil>"
+		}
+		field = field.Elem()
+	}
+	kind := field.Kind()
+	if kind == reflect.Slice || kind == reflect.Array {
+		kind = field.Type().Elem().Kind()
+	}
+	if kind == reflect.String {
+		return fmt.Sprintf("%q", field.Interface())
+	}
+	return fmt.Sprintf("%v", field.Interface())
+}
@@ -1,6 +1,10 @@
 package flatfile
 
-import "testing"
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
 
 func TestExamine(t *testing.T) {
 	type NestedStruct struct {
@@ -14,3 +18,46 @@ func TestExamine(t *testing.T) {
 
 	Examine([]ExamineStruct{})
 }
+
+func TestDump(t *testing.T) {
+	type Address struct {
+		City string `flatfile:"1,6"`
+		Zip  int    `flatfile:"7,5"`
+	}
+	type DumpStruct struct {
+		Amount  Cents    `flatfile:"11,7"`
+		Name    string   `flatfile:"1,10"`
+		Home    Address  `flatfile:"18,11"`
+		Work    *Address `flatfile:"29,11"`
+		Codes   []string `flatfile:"40,2,-1"`
+		Comment string
+	}
+
+	testVal := &DumpStruct{}
+	if err := Unmarshal([]byte("AMY       0012345TORONT12345OTTAWA54321XXYYZZ"), testVal, 0, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	testVal.Work = nil
+
+	var got bytes.Buffer
+	if err := Dump(&got, testVal); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"FIELD      COL  LEN  VALUE",
+		"Name       1    10   \"AMY       \"",
+		"Amount     11   7    123.45",
+		"Home.City  18   6    \"TORONT\"",
+		"Home.Zip   24   5    12345",
+		"Work       29   11   <nil>",
+		"Codes      40   6    [\"XX\" \"YY\" \"ZZ\"]",
+		"",
+	}, "\n")
+	if got.String() != want {
+		t.Errorf("Dump got:\n%s\nwant:\n%s", got.String(), want)
+	}
+
+	if err := Dump(&got, "not a struct"); err == nil {
+		t.Error("Dump should return an error for a value that is not a struct")
+	}
+}
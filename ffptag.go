@@ -1,6 +1,8 @@
 package flatfile
 
 import (
+	"go/token"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -16,6 +18,79 @@ type flatfileTag struct {
 	condLen  int
 	condVal  string
 	condChk  bool
+	//autoCol starts the field at the column following the previous tagged field e.g. `flatfile:"auto,10"`
+	autoCol bool
+	//lenPrefix is set when the first length bytes of the field are a decimal length of the value that follows
+	lenPrefix bool
+	//innerOccurs is the number of columns of each row when occurs is in the form rows x columns
+	innerOccurs int
+	//trueVal and falseVal map the text of a bool field e.g. `true=Y,false=N`
+	trueVal  string
+	falseVal string
+	//conv is the name of a registered Converter used to assign the field
+	conv string
+	//occursCol and occursLen locate a decimal count in the record that is used as occurs e.g. `occursAt=10:2`
+	occursCol int
+	occursLen int
+	//occursBinary reads the occursAt count as a big-endian binary integer e.g. `occursAt=10:2:binary`
+	occursBinary bool
+	//money strips currency symbols, grouping commas and accounting parentheses from float fields
+	money bool
+	//nullVal is the sentinel meaning the field is null e.g. `null=999999`, compared with surrounding whitespace removed
+	nullVal string
+	nullChk bool
+	//transforms are the names of registered Transforms applied in order after the field is assigned
+	transforms []string
+	//constVal is the literal the field must contain e.g. `const=HDR`, compared with surrounding whitespace removed
+	constVal string
+	constChk bool
+	//keepRaw opts a string field out of decoding, transforms and trimming so it holds the exact bytes of the field
+	keepRaw bool
+	//signField is the name of the struct field holding the sign of a numeric field e.g. `signField=Sign`
+	signField string
+	//percentScale is the number of implied decimals of a percentage float field e.g. `percent=3`
+	percentScale int
+	percentChk   bool
+	//fraction divides a percentage by 100 e.g. 25.5% is read as 0.255
+	fraction bool
+	//boolNumeric reads a bool field as a number where any nonzero value is true e.g. `boolmode=numeric`
+	boolNumeric bool
+	//term ends the value of the field early, the rest of the field being filler e.g. `term=0x00` or `term=|`
+	term    byte
+	termChk bool
+	//trimLeft and trimRight remove whitespace padding from a string field e.g. `ltrim`, `rtrim` or `trim` for both
+	trimLeft  bool
+	trimRight bool
+	//trimSet is the set of padding characters removed from both ends of a field before it is assigned e.g. `trimset=*`
+	trimSet string
+	//masked assigns the zero value to a field made up only of trimSet characters e.g. a protected amount `********`
+	masked bool
+	//paren reads an integer field in parentheses e.g. (123) as negative
+	paren bool
+	//bitFlags reads an unsigned field as the big-endian value of its raw bytes rather than decimal text
+	bitFlags bool
+	//zoned reads an integer field as EBCDIC zoned decimal, one digit per byte with the sign in the zone of the last byte
+	zoned bool
+	//packed reads a numeric field as IBM packed decimal (COMP-3), two digits per byte with the sign in the last nibble
+	//packedScale is its number of implied decimals e.g. `packed,scale=2`
+	packed      bool
+	packedScale int
+	//runLen reads a numeric field as the count of the fill character repeated at its start e.g. `runlen=#`
+	runLen    byte
+	runLenChk bool
+	//regex extracts the value of a field from its first capture group e.g. `regex=REF-(\d+)`
+	regex *regexp.Regexp
+	//regexZero assigns the zero value when regex does not match instead of returning an error e.g. `nomatch=zero`
+	regexZero bool
+	//layout is the time.Parse layout of a time.Time or sql.NullTime field e.g. `layout=20060102`
+	layout string
+	//timeField is the name of the struct field holding the time of day for a time.Time date field e.g. `timeField=Clock`
+	timeField string
+	//decimals is the number of decimals Marshal writes a float field with, rounding by the RoundingMode e.g. `decimals=2`
+	decimals    int
+	decimalsChk bool
+	//blankZero makes Marshal leave the field blank when its value is the zero value of its type e.g. `blankzero`
+	blankZero bool
 }
 
 var parseFuncMap = map[string]func(string, *flatfileTag) error{
@@ -29,26 +104,139 @@ var parseFuncMap = map[string]func(string, *flatfileTag) error{
 	"override":  parseOverrideOption,
 	"cond":      parseConditionOption,
 	"condition": parseConditionOption,
+	"true":      parseTrueOption,
+	"false":     parseFalseOption,
+	"conv":      parseConvOption,
+	"occursAt":  parseOccursAtOption,
+	"null":      parseNullOption,
+	"transform": parseTransformOption,
+	"const":     parseConstOption,
+	"signField": parseSignFieldOption,
+	"percent":   parsePercentOption,
+	"boolmode":  parseBoolModeOption,
+	"term":      parseTermOption,
+	"layout":    parseLayoutOption,
+	"trimset":   parseTrimSetOption,
+	"regex":     parseRegexOption,
+	"nomatch":   parseNoMatchOption,
+	"timeField": parseTimeFieldOption,
+	"runlen":    parseRunLenOption,
+	"scale":     parseScaleOption,
+	"decimals":  parseDecimalsOption,
+}
+
+//parseFlagMap holds options that are a single word with no value e.g. `flatfile:"10,3,,lenPrefix"`
+//Flags can appear in any position after column and length
+var parseFlagMap = map[string]func(*flatfileTag){
+	"lenPrefix": func(ffpTag *flatfileTag) { ffpTag.lenPrefix = true },
+	"money":     func(ffpTag *flatfileTag) { ffpTag.money = true },
+	"keepraw":   func(ffpTag *flatfileTag) { ffpTag.keepRaw = true },
+	"fraction":  func(ffpTag *flatfileTag) { ffpTag.fraction = true },
+	"ltrim":     func(ffpTag *flatfileTag) { ffpTag.trimLeft = true },
+	"rtrim":     func(ffpTag *flatfileTag) { ffpTag.trimRight = true },
+	"trim":      func(ffpTag *flatfileTag) { ffpTag.trimLeft, ffpTag.trimRight = true, true },
+	"masked":    func(ffpTag *flatfileTag) { ffpTag.masked = true },
+	"bitflags":  func(ffpTag *flatfileTag) { ffpTag.bitFlags = true },
+	"paren":     func(ffpTag *flatfileTag) { ffpTag.paren = true },
+	"zoned":     func(ffpTag *flatfileTag) { ffpTag.zoned = true },
+	"packed":    func(ffpTag *flatfileTag) { ffpTag.packed = true },
+	"ip":        func(ffpTag *flatfileTag) { ffpTag.conv = "ip" },
+	"uuid":      func(ffpTag *flatfileTag) { ffpTag.conv = "uuid" },
+	"blankzero": func(ffpTag *flatfileTag) { ffpTag.blankZero = true },
 }
 
 //condition=1-10-TENLETTERS
 
-//validOptions is a list of the keys loaded from parseFuncMap. This is used purely to display options to user
+//ignoreTag marks a field that is not part of the record e.g. `flatfile:"-"`
+const ignoreTag = "-"
+
+//autoColumn is the column of a field that starts where the previous tagged field ends e.g. `flatfile:"auto,10"`
+const autoColumn = "auto"
+
+//maxBinaryOccursLen is the most bytes of a binary occursAt count, a 4 byte COMP count
+const maxBinaryOccursLen = 4
+
+//MaxFieldLength is the most bytes a single field may span, its length times any occurs
+//Tags declaring more are rejected to guard against absurd lengths from untrusted or generated layouts
+//Set it before the first Unmarshal as struct tags are parsed once per type
+var MaxFieldLength = 1 << 20
+
+//greedyOccurs is the occurs sentinel meaning repeat until the remaining data is exhausted
+const greedyOccurs = -1
+
+//validOptions is a list of the keys loaded from parseFuncMap and parseFlagMap. This is used purely to display options to user
 var validOptions []string
 
 func init() {
 	for key := range parseFuncMap {
 		validOptions = append(validOptions, key)
 	}
+	for key := range parseFlagMap {
+		validOptions = append(validOptions, key)
+	}
+}
+
+//profileSeparator separates the layout profiles of a tag e.g. `flatfile:"1,10;v2=1,12"`
+const profileSeparator = ";"
+
+//profileTag returns the part of fieldTag that applies to the named profile
+//Each profile is written name=tag and an unnamed tag is the default profile, or the first profile if all are named
+//The default applies when profile is empty or the tag has no profile of that name. A tag without profiles applies to all of them
+func profileTag(fieldTag string, profile string) (string, error) {
+	if !strings.Contains(fieldTag, profileSeparator) && profileName(fieldTag) == "" {
+		return fieldTag, nil
+	}
+	firstTag := ""
+	profiles := make(map[string]string)
+	for idx, segment := range strings.Split(fieldTag, profileSeparator) {
+		segment = strings.TrimSpace(segment)
+		name := profileName(segment)
+		tag := segment
+		if name != "" {
+			tag = strings.TrimSpace(segment[strings.Index(segment, "=")+1:])
+		}
+		if _, exists := profiles[name]; exists {
+			return "", errors.Errorf("flatfile.profileTag: Profile %q is declared more than once in tag %s", name, fieldTag)
+		}
+		profiles[name] = tag
+		if idx == 0 {
+			firstTag = tag
+		}
+	}
+	if tag, exists := profiles[profile]; exists {
+		return tag, nil
+	}
+	if tag, exists := profiles[""]; exists {
+		return tag, nil
+	}
+	return firstTag, nil
+}
+
+//profileName returns the name of the profile a tag segment starts with e.g. v2 for v2=1,12, or "" if it is unnamed
+//Named options such as col=1 are not profile names
+func profileName(segment string) string {
+	eq := strings.Index(segment, "=")
+	if eq < 0 || strings.Contains(segment[:eq], ",") {
+		return ""
+	}
+	name := strings.TrimSpace(segment[:eq])
+	if _, isOption := parseFuncMap[name]; isOption || !token.IsIdentifier(name) {
+		return ""
+	}
+	return name
 }
 
 //parseFlatfileTag parses an ffp struct tag on a field
 //Tags are expected to be in the form:
 // col,len,occurs
-// where col is an int > 0
+// where col is an int > 0, or auto to start where the previous tagged field ends
 //		 len is an int
+//		 occurs is an int >= 1, or -1 to repeat until the remaining data is exhausted
+//Positional options after len may be left empty e.g. `10,3,,lenPrefix`
 func parseFlatfileTag(fieldTag string, ffpTag *flatfileTag) error {
 	var err error
+	//reset tag so options from a previously parsed field do not carry over
+	*ffpTag = flatfileTag{}
 	//split tag by comma to get column and length data
 	params := strings.Split(fieldTag, ",")
 	//column and length parameters must be provided
@@ -68,6 +256,11 @@ func parseFlatfileTag(fieldTag string, ffpTag *flatfileTag) error {
 			} else {
 				return errors.Errorf("flatfile.parseFlatfileTag: Invalid tag parameter %s\nValid options: %v", options[0], validOptions)
 			}
+		} else if flagFunc, exists := parseFlagMap[param]; exists && idx > 1 {
+			flagFunc(ffpTag)
+		} else if param == "" && idx > 1 {
+			//positional option left empty
+			continue
 		} else {
 			//assume user is using positional options
 			switch idx {
@@ -88,20 +281,48 @@ func parseFlatfileTag(fieldTag string, ffpTag *flatfileTag) error {
 		}
 	}
 
-	if ffpTag.length == 0 || ffpTag.col == 0 {
+	if ffpTag.length == 0 || (ffpTag.col == 0 && !ffpTag.autoCol) {
 		return errors.New("flatfile.parseFlatfileTag: Column or length option not provided")
 	}
+	if err := checkFieldLength(ffpTag); err != nil {
+		return err
+	}
+	if ffpTag.packedScale > 0 && !ffpTag.packed {
+		return errors.New("flatfile.parseFlatfileTag: scale can only be used with the packed option")
+	}
+	if ffpTag.packed && ffpTag.packedScale > 2*ffpTag.length-1 {
+		return errors.Errorf("flatfile.parseFlatfileTag: Out of range error. packed implied decimals %d cannot exceed the %d digits of the field", ffpTag.packedScale, 2*ffpTag.length-1)
+	}
+	if ffpTag.fraction && !ffpTag.percentChk {
+		return errors.New("flatfile.parseFlatfileTag: fraction can only be used with the percent option")
+	}
+	if ffpTag.keepRaw && (len(ffpTag.transforms) > 0 || ffpTag.trimLeft || ffpTag.trimRight || ffpTag.trimSet != "") {
+		return errors.New("flatfile.parseFlatfileTag: keepraw cannot be used with transform or trim options")
+	}
+	if ffpTag.masked && ffpTag.trimSet == "" {
+		return errors.New("flatfile.parseFlatfileTag: masked can only be used with the trimset option")
+	}
+	if ffpTag.regexZero && ffpTag.regex == nil {
+		return errors.New("flatfile.parseFlatfileTag: nomatch can only be used with the regex option")
+	}
+	if ffpTag.occursCol > 0 && ffpTag.occurs != 0 {
+		return errors.New("flatfile.parseFlatfileTag: occurs and occursAt options cannot be used together")
+	}
 	return nil
 }
 
 func parseColumnOption(param string, ffpTag *flatfileTag) error {
+	if param == autoColumn {
+		ffpTag.autoCol = true
+		return nil
+	}
 	col, colerr := strconv.Atoi(param)
 	if colerr != nil {
 		return errors.Wrapf(colerr, "flatfile.parseColumnOption: Error parsing tag column parameter %s", param)
 	}
 
 	if col < 1 {
-		return errors.Errorf("flatfile.parseColumnOption: Out of range error. Column parameter cannot be less than 1. Please note column is 1-indexed not zero")
+		return errors.Errorf("flatfile.parseColumnOption: Out of range error. Column parameter %d cannot be less than 1. Please note column is 1-indexed not zero", col)
 	}
 	ffpTag.col = col
 	return nil
@@ -115,21 +336,38 @@ func parseLengthOption(param string, ffpTag *flatfileTag) error {
 	}
 
 	if length < 1 {
-		return errors.Errorf("flatfile.parseLengthOption: Out of range error. Length parameter cannot be less than 1")
+		return errors.Errorf("flatfile.parseLengthOption: Out of range error. Length parameter %d cannot be less than 1", length)
 	}
 
 	ffpTag.length = length
 	return nil
 }
 
+//parseOccursOption parses occurs as a single count e.g. 3 or as rows x columns e.g. 3x4 for a slice of slices
 func parseOccursOption(param string, ffpTag *flatfileTag) error {
-	occurs, occerr := strconv.Atoi(param)
+	dims := strings.Split(param, "x")
+	if len(dims) > 2 {
+		return errors.Errorf("flatfile.parseOccursOption: Occurs parameter %s can have at most 2 dimensions e.g. 3x4", param)
+	}
+
+	occurs, occerr := strconv.Atoi(dims[0])
 	if occerr != nil {
 		return errors.Wrapf(occerr, "flatfile.parseOccursOption: Error parsing tag occurs parameter %s", param)
 	}
 
-	if occurs < 2 {
-		return errors.Errorf("flatfile.parseOccursOption: Out of range error. Occurs parameter cannot be less than 2")
+	if occurs < 1 && occurs != greedyOccurs {
+		return errors.Errorf("flatfile.parseOccursOption: Out of range error. Occurs parameter %d cannot be less than 1 unless it is %d to consume the remaining data", occurs, greedyOccurs)
+	}
+
+	if len(dims) == 2 {
+		innerOccurs, innererr := strconv.Atoi(dims[1])
+		if innererr != nil {
+			return errors.Wrapf(innererr, "flatfile.parseOccursOption: Error parsing tag occurs columns parameter %s", param)
+		}
+		if innerOccurs < 1 {
+			return errors.Errorf("flatfile.parseOccursOption: Out of range error. Occurs columns parameter %d cannot be less than 1", innerOccurs)
+		}
+		ffpTag.innerOccurs = innerOccurs
 	}
 
 	ffpTag.occurs = occurs
@@ -169,6 +407,10 @@ func parseConditionOption(param string, ffpTag *flatfileTag) error {
 		return errors.Wrapf(lenerr, "flatfile.parseConditionOption: Error parsing tag condition len parameter %s", param)
 	}
 
+	if condCol < 1 || condLen < 1 {
+		return errors.Errorf("flatfile.parseConditionOption: Out of range error. Condition col %d and len %d cannot be less than 1", condCol, condLen)
+	}
+
 	condVal := condParams[2]
 
 	ffpTag.condCol = condCol
@@ -177,3 +419,236 @@ func parseConditionOption(param string, ffpTag *flatfileTag) error {
 	ffpTag.condChk = true
 	return nil
 }
+
+func parseTrueOption(param string, ffpTag *flatfileTag) error {
+	ffpTag.trueVal = strings.TrimSpace(param)
+	if ffpTag.trueVal == "" {
+		return errors.New("flatfile.parseTrueOption: True value cannot be blank")
+	}
+	return nil
+}
+
+func parseFalseOption(param string, ffpTag *flatfileTag) error {
+	ffpTag.falseVal = strings.TrimSpace(param)
+	if ffpTag.falseVal == "" {
+		return errors.New("flatfile.parseFalseOption: False value cannot be blank. Blank bool fields are already false")
+	}
+	return nil
+}
+
+func parseConvOption(param string, ffpTag *flatfileTag) error {
+	if _, ok := lookupConverter(param); !ok {
+		return errors.Errorf("flatfile.parseConvOption: Converter %s is not registered. Use flatfile.RegisterConverter", param)
+	}
+	ffpTag.conv = param
+	return nil
+}
+
+//parseOccursAtOption parses the location of an occurs count in the record as col:len e.g. 10:2
+func parseOccursAtOption(param string, ffpTag *flatfileTag) error {
+	occursParams := strings.Split(param, ":")
+	if len(occursParams) != 2 && len(occursParams) != 3 {
+		return errors.Errorf("flatfile.parseOccursAtOption: Expected occursAt in the form col:len or col:len:binary but got %s", param)
+	}
+	occursCol, colerr := strconv.Atoi(occursParams[0])
+	if colerr != nil {
+		return errors.Wrapf(colerr, "flatfile.parseOccursAtOption: Error parsing tag occursAt col parameter %s", param)
+	}
+	occursLen, lenerr := strconv.Atoi(occursParams[1])
+	if lenerr != nil {
+		return errors.Wrapf(lenerr, "flatfile.parseOccursAtOption: Error parsing tag occursAt len parameter %s", param)
+	}
+	if occursCol < 1 || occursLen < 1 {
+		return errors.Errorf("flatfile.parseOccursAtOption: Out of range error. occursAt col %d and len %d cannot be less than 1", occursCol, occursLen)
+	}
+	if len(occursParams) == 3 {
+		if occursParams[2] != "binary" {
+			return errors.Errorf("flatfile.parseOccursAtOption: Unknown occursAt count mode %s. Expected binary", occursParams[2])
+		}
+		if occursLen > maxBinaryOccursLen {
+			return errors.Errorf("flatfile.parseOccursAtOption: Out of range error. A binary occursAt count cannot be longer than %d bytes", maxBinaryOccursLen)
+		}
+		ffpTag.occursBinary = true
+	}
+	ffpTag.occursCol = occursCol
+	ffpTag.occursLen = occursLen
+	return nil
+}
+
+//parseNullOption sets the null sentinel of a field. An empty value e.g. `null=` treats a blank field as null
+func parseNullOption(param string, ffpTag *flatfileTag) error {
+	ffpTag.nullVal = strings.TrimSpace(param)
+	ffpTag.nullChk = true
+	return nil
+}
+
+//parseTransformOption parses the names of one or more registered transforms separated by | e.g. trimspace|upper
+func parseTransformOption(param string, ffpTag *flatfileTag) error {
+	for _, name := range strings.Split(param, "|") {
+		if _, ok := lookupTransform(name); !ok {
+			return errors.Errorf("flatfile.parseTransformOption: Transform %s is not registered. Use flatfile.RegisterTransform", name)
+		}
+		ffpTag.transforms = append(ffpTag.transforms, name)
+	}
+	return nil
+}
+
+func parseConstOption(param string, ffpTag *flatfileTag) error {
+	ffpTag.constVal = strings.TrimSpace(param)
+	if ffpTag.constVal == "" {
+		return errors.New("flatfile.parseConstOption: Constant value cannot be blank")
+	}
+	ffpTag.constChk = true
+	return nil
+}
+
+func parseSignFieldOption(param string, ffpTag *flatfileTag) error {
+	ffpTag.signField = strings.TrimSpace(param)
+	if ffpTag.signField == "" {
+		return errors.New("flatfile.parseSignFieldOption: Sign field name cannot be blank")
+	}
+	return nil
+}
+
+func parseTimeFieldOption(param string, ffpTag *flatfileTag) error {
+	ffpTag.timeField = strings.TrimSpace(param)
+	if ffpTag.timeField == "" {
+		return errors.New("flatfile.parseTimeFieldOption: Time field name cannot be blank")
+	}
+	return nil
+}
+
+func parsePercentOption(param string, ffpTag *flatfileTag) error {
+	scale, err := strconv.Atoi(param)
+	if err != nil {
+		return errors.Wrapf(err, "flatfile.parsePercentOption: Error parsing tag percent parameter %s", param)
+	}
+	if scale < 0 {
+		return errors.Errorf("flatfile.parsePercentOption: Out of range error. Percent implied decimals %d cannot be less than 0", scale)
+	}
+	ffpTag.percentScale = scale
+	ffpTag.percentChk = true
+	return nil
+}
+
+//parseScaleOption sets the number of implied decimals of a packed decimal field e.g. `packed,scale=2` reads 12 34 5C as 123.45
+func parseScaleOption(param string, ffpTag *flatfileTag) error {
+	scale, err := strconv.Atoi(param)
+	if err != nil {
+		return errors.Wrapf(err, "flatfile.parseScaleOption: Error parsing tag scale parameter %s", param)
+	}
+	if scale < 0 {
+		return errors.Errorf("flatfile.parseScaleOption: Out of range error. Packed implied decimals %d cannot be less than 0", scale)
+	}
+	ffpTag.packedScale = scale
+	return nil
+}
+
+//parseDecimalsOption sets the number of decimals Marshal writes a float field with e.g. `decimals=2` writes 2.5 as 2.50
+func parseDecimalsOption(param string, ffpTag *flatfileTag) error {
+	decimals, err := strconv.Atoi(param)
+	if err != nil {
+		return errors.Wrapf(err, "flatfile.parseDecimalsOption: Error parsing tag decimals parameter %s", param)
+	}
+	if decimals < 0 {
+		return errors.Errorf("flatfile.parseDecimalsOption: Out of range error. Decimals %d cannot be less than 0", decimals)
+	}
+	ffpTag.decimals = decimals
+	ffpTag.decimalsChk = true
+	return nil
+}
+
+func parseBoolModeOption(param string, ffpTag *flatfileTag) error {
+	if param != "numeric" {
+		return errors.Errorf("flatfile.parseBoolModeOption: Invalid bool mode %s. Valid modes: [numeric]", param)
+	}
+	ffpTag.boolNumeric = true
+	return nil
+}
+
+//parseTermOption parses a terminator byte given as a single character e.g. | or in hex e.g. 0x00
+func parseTermOption(param string, ffpTag *flatfileTag) error {
+	switch {
+	case len(param) == 1:
+		ffpTag.term = param[0]
+	case len(param) == 4 && strings.HasPrefix(param, "0x"):
+		term, err := strconv.ParseUint(param[2:], 16, 8)
+		if err != nil {
+			return errors.Wrapf(err, "flatfile.parseTermOption: Error parsing tag term parameter %s", param)
+		}
+		ffpTag.term = byte(term)
+	default:
+		return errors.Errorf("flatfile.parseTermOption: Terminator %s must be a single character or a hex byte e.g. 0x00", param)
+	}
+	ffpTag.termChk = true
+	return nil
+}
+
+//parseRunLenOption parses the single fill character counted by a run length field e.g. #
+func parseRunLenOption(param string, ffpTag *flatfileTag) error {
+	if len(param) != 1 {
+		return errors.Errorf("flatfile.parseRunLenOption: Fill %s must be a single character", param)
+	}
+	ffpTag.runLen = param[0]
+	ffpTag.runLenChk = true
+	return nil
+}
+
+//parseLayoutOption sets the time.Parse layout of a time field. Layouts cannot contain a comma
+func parseLayoutOption(param string, ffpTag *flatfileTag) error {
+	if param == "" {
+		return errors.New("flatfile.parseLayoutOption: Time layout cannot be empty")
+	}
+	ffpTag.layout = param
+	return nil
+}
+
+//parseTrimSetOption sets the padding characters trimmed from both ends of a field e.g. `trimset=*` or `trimset=*0`
+func parseTrimSetOption(param string, ffpTag *flatfileTag) error {
+	if param == "" {
+		return errors.New("flatfile.parseTrimSetOption: Trim set cannot be empty")
+	}
+	ffpTag.trimSet = param
+	return nil
+}
+
+//checkFieldLength rejects a field spanning more than MaxFieldLength bytes without overflowing on absurd values
+func checkFieldLength(ffpTag *flatfileTag) error {
+	width := ffpTag.length
+	for _, occurs := range []int{ffpTag.occurs, ffpTag.innerOccurs} {
+		if occurs < 1 {
+			continue
+		}
+		if width > MaxFieldLength/occurs {
+			width = MaxFieldLength + 1
+			break
+		}
+		width *= occurs
+	}
+	if width > MaxFieldLength {
+		return errors.Errorf("flatfile.parseFlatfileTag: Field of length %d occurs %d spans more than MaxFieldLength %d bytes", ffpTag.length, ffpTag.occurs, MaxFieldLength)
+	}
+	return nil
+}
+
+//parseRegexOption compiles the expression a field value is extracted with. It cannot contain a comma or equals sign
+func parseRegexOption(param string, ffpTag *flatfileTag) error {
+	regex, err := regexp.Compile(param)
+	if err != nil {
+		return errors.Wrapf(err, "flatfile.parseRegexOption: Invalid regex %s", param)
+	}
+	ffpTag.regex = regex
+	return nil
+}
+
+func parseNoMatchOption(param string, ffpTag *flatfileTag) error {
+	switch param {
+	case "error":
+		ffpTag.regexZero = false
+	case "zero":
+		ffpTag.regexZero = true
+	default:
+		return errors.Errorf("flatfile.parseNoMatchOption: Invalid nomatch mode %s. Valid modes: [error zero]", param)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package flatfile
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -26,11 +27,17 @@ func TestFfpTagOptions_parseFfpTag(t *testing.T) {
 		{"col=1,len=1,occ=2,ovr=byte", &flatfileTag{}, &flatfileTag{col: 1, length: 1, occurs: 2, override: "byte", condChk: false, condCol: 0, condLen: 0, condVal: ""}, false},
 		{"col=1,len=1,occ=2,override=rune", &flatfileTag{}, &flatfileTag{col: 1, length: 1, occurs: 2, override: "rune", condChk: false, condCol: 0, condLen: 0, condVal: ""}, false},
 		{"col=1,len=1,occ=2,override=rune,cond=1-10-tenletters", &flatfileTag{}, &flatfileTag{col: 1, length: 1, occurs: 2, override: "rune", condChk: true, condCol: 1, condLen: 10, condVal: "tenletters"}, false},
+		{"1,6,decimals=2", &flatfileTag{}, &flatfileTag{col: 1, length: 6, decimals: 2, decimalsChk: true}, false},
+		{"1,6,decimals=-1", &flatfileTag{}, &flatfileTag{}, true},
+		{"1,6,decimals=x", &flatfileTag{}, &flatfileTag{}, true},
 		{"override=rune,cond=3-1-1", &flatfileTag{}, &flatfileTag{col: 1, length: 1, occurs: 0, override: "", condChk: false, condCol: 0, condLen: 0, condVal: ""}, true},
 		{"col=1=1,len=3", &flatfileTag{}, &flatfileTag{col: 1, length: 1, occurs: 0, override: "", condChk: false, condCol: 0, condLen: 0, condVal: ""}, true},
 		{"1,2,fake=1", &flatfileTag{}, &flatfileTag{col: 1, length: 1, occurs: 0, override: "", condChk: false, condCol: 0, condLen: 0, condVal: ""}, true},
 		{"col=1,len=3,occ=once", &flatfileTag{}, &flatfileTag{col: 1, length: 1, occurs: 0, override: "", condChk: false, condCol: 0, condLen: 0, condVal: ""}, true},
-		{"col=1,len=3,occ=1", &flatfileTag{}, &flatfileTag{col: 1, length: 1, occurs: 0, override: "", condChk: false, condCol: 0, condLen: 0, condVal: ""}, true},
+		{"col=1,len=3,occ=1", &flatfileTag{}, &flatfileTag{col: 1, length: 3, occurs: 1, override: "", condChk: false, condCol: 0, condLen: 0, condVal: ""}, false},
+		{"col=1,len=3,occ=0", &flatfileTag{}, &flatfileTag{col: 1, length: 1, occurs: 0, override: "", condChk: false, condCol: 0, condLen: 0, condVal: ""}, true},
+		{"col=1,len=1,occ=-1", &flatfileTag{}, &flatfileTag{col: 1, length: 1, occurs: -1, override: "", condChk: false, condCol: 0, condLen: 0, condVal: ""}, false},
+		{"col=1,len=3,occ=-2", &flatfileTag{}, &flatfileTag{col: 1, length: 1, occurs: 0, override: "", condChk: false, condCol: 0, condLen: 0, condVal: ""}, true},
 		{"col=1,len=3,occ=2,ovr=uintptr", &flatfileTag{}, &flatfileTag{col: 1, length: 1, occurs: 0, override: "", condChk: false, condCol: 0, condLen: 0, condVal: ""}, true},
 		{"col=1,len=3,occ=2,ovr=byte,cond=1-2", &flatfileTag{}, &flatfileTag{col: 1, length: 1, occurs: 0, override: "", condChk: false, condCol: 0, condLen: 0, condVal: ""}, true},
 		{"col=1,len=3,occ=2,ovr=byte,cond=one-2-to", &flatfileTag{}, &flatfileTag{col: 1, length: 1, occurs: 0, override: "", condChk: false, condCol: 0, condLen: 0, condVal: ""}, true},
@@ -116,3 +123,65 @@ func TestFfpTagParsePosSyntaxErr_parseFfpTag(t *testing.T) {
 	t.Log(testVal)
 	t.Log(err)
 }
+
+func TestFfpTagFlags_parseFfpTag(t *testing.T) {
+	var tests = []struct {
+		tagValue string
+		WantTag  flatfileTag
+		isError  bool
+	}{
+		{"10,3,,lenPrefix", flatfileTag{col: 10, length: 3, lenPrefix: true}, false},
+		{"col=10,len=3,lenPrefix", flatfileTag{col: 10, length: 3, lenPrefix: true}, false},
+		{"10,3", flatfileTag{col: 10, length: 3}, false},
+		{"lenPrefix,3", flatfileTag{}, true},
+		{"10,3,,notAFlag", flatfileTag{}, true},
+		{"1,1,true=Y,false=N", flatfileTag{col: 1, length: 1, trueVal: "Y", falseVal: "N"}, false},
+		{"1,1,true= ", flatfileTag{}, true},
+		{"1,5,3x4", flatfileTag{col: 1, length: 5, occurs: 3, innerOccurs: 4}, false},
+		{"1,5,-1x4", flatfileTag{col: 1, length: 5, occurs: -1, innerOccurs: 4}, false},
+		{"1,5,3x0", flatfileTag{}, true},
+		{"1,5,3x4x2", flatfileTag{}, true},
+		{"1,5,3xfour", flatfileTag{}, true},
+		{"1,5,transform=upper", flatfileTag{col: 1, length: 5, transforms: []string{"upper"}}, false},
+		{"1,5,transform=trimspace|lower", flatfileTag{col: 1, length: 5, transforms: []string{"trimspace", "lower"}}, false},
+		{"1,5,transform=upper|shout", flatfileTag{}, true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestFfpTagFlags_parseFfpTag-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := &flatfileTag{}
+			err := parseFlatfileTag(tt.tagValue, got)
+			if (err != nil) != tt.isError {
+				t.Errorf("parseFfpTag(%v) err: %v want error: %v", tt.tagValue, err, tt.isError)
+			}
+			if err == nil && !reflect.DeepEqual(*got, tt.WantTag) {
+				t.Errorf("parseFfpTag(%v) got: %+v want: %+v", tt.tagValue, *got, tt.WantTag)
+			}
+		})
+	}
+}
+
+func TestFfpTagMaxFieldLengthErr_parseFfpTag(t *testing.T) {
+	var tests = []struct {
+		Tag     string
+		WantErr bool
+	}{
+		{"1,1048576", false},
+		{"1,999999999", true},
+		{"1,1024,1024", false},
+		{"1,1024,1025", true},
+		{"1,2,9223372036854775807", true},
+		{"1,2,1000x1000", true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestFfpTagMaxFieldLengthErr-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			err := parseFlatfileTag(tt.Tag, &flatfileTag{})
+			if (err != nil) != tt.WantErr {
+				t.Errorf("parseFlatfileTag(%s) err: %v want err: %v", tt.Tag, err, tt.WantErr)
+			}
+		})
+	}
+}
@@ -3,39 +3,62 @@ package flatfile
 import (
 	"bufio"
 	"fmt"
-	"io"
 	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 )
 
 //FlatFile is an abstraction for a flat file containing structured data
+//Records are read by a Decoder so FlatFile, Decoder and ParseBatch split lines the same way
 type FlatFile struct {
-	reader       *bufio.Reader
+	decoder      *Decoder
 	objectLayout interface{}
 }
 
 //New returns a new FlatFile reader object
 func New(reader *bufio.Reader, objectLayout interface{}) (*FlatFile, error) {
 	if reflect.TypeOf(objectLayout).Kind() == reflect.Ptr {
-		return &FlatFile{reader: reader, objectLayout: objectLayout}, nil
+		return &FlatFile{decoder: newDecoder(reader, "flatfile.FlatFile.Read"), objectLayout: objectLayout}, nil
 	}
 
 	return nil, errors.Wrap(fmt.Errorf("flatfile.New: %s is not a pointer", reflect.TypeOf(objectLayout)), "")
 }
 
 //Read will read a line from a bufio.Reader and call flatfile.Unmarshal to convert the read in data into FlatFile.objectLayout
-func (f *FlatFile) Read() (err error) {
-	var line []byte
-	var buffLine []byte
-	prefix := true
-	for prefix {
-		buffLine, prefix, err = f.reader.ReadLine()
-		if err == io.EOF {
-			return err
-		}
-		line = append(line, buffLine...)
+//Lines may end in \n or \r\n and a UTF-8 byte order mark at the start of the file is skipped
+//An error unmarshalling a line includes its record number and byte offset in the file to help locate it
+func (f *FlatFile) Read() error {
+	return f.decoder.Decode(f.objectLayout)
+}
+
+//Offset returns the byte offset in the file of the next line Read will return
+func (f *FlatFile) Offset() int64 {
+	return f.decoder.Offset()
+}
+
+//CheckSequence makes Read verify the sequence number field fieldName of each record like Decoder.CheckSequence
+//An error is returned if the object layout has no integer or string field fieldName
+func (f *FlatFile) CheckSequence(fieldName string, start, step int64) error {
+	layoutType := reflect.TypeOf(f.objectLayout).Elem()
+	if _, err := sequenceField(layoutType, fieldName); err != nil {
+		return errors.Wrap(err, "flatfile.CheckSequence")
 	}
+	f.decoder.CheckSequence(fieldName, start, step)
+	return nil
+}
 
-	return Unmarshal(line, f.objectLayout, 0, 0, false)
+//intFieldValue returns the value of an integer field, or of a string field holding a decimal integer
+func intFieldValue(field reflect.Value) (int64, error) {
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return field.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(field.Uint()), nil
+	case reflect.String:
+		value, err := strconv.ParseInt(strings.TrimSpace(field.String()), 10, 64)
+		return value, errors.Wrap(err, "flatfile.intFieldValue")
+	}
+	return 0, errors.Errorf("flatfile.intFieldValue: %s is not an integer or string", field.Type())
 }
@@ -2,6 +2,7 @@ package flatfile
 
 import (
 	"bufio"
+	"errors"
 	"io"
 	"strings"
 	"testing"
@@ -70,3 +71,122 @@ func TestFlatFileRead(t *testing.T) {
 		})
 	}
 }
+
+func TestFlatFileReadLineEndings(t *testing.T) {
+	type longType struct {
+		Data   string `flatfile:"1,20"`
+		Number string `flatfile:"21,1"`
+	}
+
+	testCases := []struct {
+		desc string
+		data string
+		want []longType
+	}{
+		{
+			desc: "BOM and CRLF",
+			data: "\xEF\xBB\xBFDATA!DATA!DATA!DATA!1\r\nDATA!DATA!DATA!DATA!2\r\n",
+			want: []longType{{"DATA!DATA!DATA!DATA!", "1"}, {"DATA!DATA!DATA!DATA!", "2"}},
+		},
+		{
+			desc: "Mixed line endings",
+			data: "DATA!DATA!DATA!DATA!1\nDATA!DATA!DATA!DATA!2\r\nDATA!DATA!DATA!DATA!3\r",
+			want: []longType{{"DATA!DATA!DATA!DATA!", "1"}, {"DATA!DATA!DATA!DATA!", "2"}, {"DATA!DATA!DATA!DATA!", "3"}},
+		},
+		{
+			desc: "Short record CR not read as a field",
+			data: "\xEF\xBB\xBFDATA!DATA!DATA!DATA!\r\n",
+			want: []longType{{"DATA!DATA!DATA!DATA!", ""}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			//a small buffer splits the \r and \n of the first line across reads
+			reader := bufio.NewReaderSize(strings.NewReader(tC.data), 21)
+			got := &longType{}
+			file, err := New(reader, got)
+			if err != nil {
+				t.Fatalf("Unexpected error %s", err.Error())
+			}
+			for i, want := range tC.want {
+				*got = longType{}
+				if err := file.Read(); err != nil {
+					t.Fatalf("Unexpected error %s", err.Error())
+				}
+				if *got != want {
+					t.Errorf("flatfile.Read() line %d got %q want %q", i, *got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFlatFileCheckSequence(t *testing.T) {
+	type seqType struct {
+		Seq  int    `flatfile:"1,3"`
+		Data string `flatfile:"4,2"`
+	}
+
+	reader := bufio.NewReader(strings.NewReader("010AA\n020BB\n020CC\n040DD\n050EE\n"))
+	got := &seqType{}
+	file, err := New(reader, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.CheckSequence("Seq", 10, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	wantErrs := []string{"", "", "Record 3 at byte 12: Sequence field Seq expected 30 but got 20", "Record 4 at byte 18: Sequence field Seq expected 30 but got 40", ""}
+	for i, wantErr := range wantErrs {
+		err := file.Read()
+		if wantErr == "" && err != nil {
+			t.Errorf("flatfile.Read() line %d unexpected error %v", i+1, err)
+		}
+		if wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr)) {
+			t.Errorf("flatfile.Read() line %d err: %v want message containing: %s", i+1, err, wantErr)
+		}
+	}
+	if err := file.Read(); err != io.EOF {
+		t.Errorf("flatfile.Read() got %v want EOF", err)
+	}
+
+	if err := file.CheckSequence("Missing", 1, 1); err == nil {
+		t.Error("CheckSequence should return an error for a missing field")
+	}
+	if err := file.CheckSequence("Data", 1, 1); err != nil {
+		t.Errorf("CheckSequence should accept a string field got: %v", err)
+	}
+}
+
+func TestFlatFileReadErrorOffset(t *testing.T) {
+	reader := bufio.NewReader(strings.NewReader("HelloWorld1\r\nHelloWorldX\nHelloWorld3"))
+	got := &testType{}
+	file, err := New(reader, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantErrs := []string{"", "Record 2 at byte 13", ""}
+	for i, wantErr := range wantErrs {
+		err := file.Read()
+		if wantErr == "" && err != nil {
+			t.Errorf("flatfile.Read() line %d unexpected error %v", i+1, err)
+		}
+		if wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), wantErr) {
+				t.Errorf("flatfile.Read() line %d err: %v want message containing: %s", i+1, err, wantErr)
+			}
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) || fieldErr.Field != "Number" {
+				t.Errorf("flatfile.Read() line %d err: %v should wrap a FieldError for Number", i+1, err)
+			}
+		}
+	}
+	if file.Offset() != 36 {
+		t.Errorf("flatfile.Offset() got %d want 36", file.Offset())
+	}
+	if err := file.Read(); err != io.EOF {
+		t.Errorf("flatfile.Read() got %v want EOF", err)
+	}
+}
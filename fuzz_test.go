@@ -0,0 +1,47 @@
+//go:build go1.18
+// +build go1.18
+
+package flatfile
+
+import (
+	"testing"
+)
+
+type fuzzNested struct {
+	Code  string `flatfile:"1,2"`
+	Count uint8  `flatfile:"3,1"`
+}
+
+type fuzzRecord struct {
+	Type     string      `flatfile:"1,1"`
+	Amount   int         `flatfile:"2,4"`
+	Rate     float64     `flatfile:"6,3,percent=1"`
+	Flag     bool        `flatfile:"9,1,true=Y,false=N"`
+	Initial  byte        `flatfile:"10,1,override=byte"`
+	Letter   rune        `flatfile:"11,1,override=rune"`
+	Digits   [3]int8     `flatfile:"12,1"`
+	Nested   fuzzNested  `flatfile:"15,3"`
+	Pointer  *fuzzNested `flatfile:"15,3"`
+	Money    *float32    `flatfile:"18,6,money"`
+	Count    string      `flatfile:"24,1"`
+	Names    []string    `flatfile:"26,2,occursAt=24:1"`
+	Table    [][]uint16  `flatfile:"26,1,2x2"`
+	Runes    []rune      `flatfile:"30,4,override=rune"`
+	Detail   string      `flatfile:"34,3,cond=1-1-D"`
+	Prefixed string      `flatfile:"37,2,lenPrefix"`
+	Rest     []string    `flatfile:"37,1,-1"`
+}
+
+// FuzzUnmarshal checks Unmarshal returns an error rather than panicking on arbitrary data
+func FuzzUnmarshal(f *testing.F) {
+	f.Add([]byte("D012305010YAZ123AB1 $1,2.32AABBCCabcdDDD03xyz"))
+	f.Add([]byte("H"))
+	f.Add([]byte(""))
+	f.Add([]byte("D0123050"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Unmarshal(data, &fuzzRecord{}, 0, 0, false)
+		Unmarshal(data, &fuzzRecord{}, 0, 0, false, WithPartialLastField(), WithEncoding(Windows1252))
+		Unmarshal(data, &fuzzRecord{}, 3, 4, true)
+		CalcNumFieldsToUnmarshal(data, &fuzzRecord{}, 0)
+	})
+}
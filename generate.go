@@ -0,0 +1,35 @@
+package flatfile
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+
+	"github.com/pkg/errors"
+)
+
+//GenerateStruct returns Go source for a struct type named typeName with a tagged field for each schema field
+//Field types are the schema types, slices for repeating fields. The source is gofmt formatted and has no package clause
+//It suits a go:generate step turning a layout spec into a typed struct
+func GenerateStruct(schema *Schema, typeName string) (string, error) {
+	if !token.IsIdentifier(typeName) {
+		return "", errors.Errorf("flatfile.GenerateStruct: Type name %q is not a Go identifier", typeName)
+	}
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "type %s struct {\n", typeName)
+	for i := range schema.Fields {
+		field := &schema.Fields[i]
+		if !isExportedIdentifier(field.Name) {
+			return "", errors.Errorf("flatfile.GenerateStruct: Field %s is not an exported Go identifier", field.Name)
+		}
+		fmt.Fprintf(&src, "%s %s `flatfile:\"%s\"`\n", field.Name, field.valueType(), field.rawTag)
+	}
+	src.WriteString("}\n")
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return "", errors.Wrap(err, "flatfile.GenerateStruct: Failed to format struct source")
+	}
+	return string(formatted), nil
+}
@@ -0,0 +1,262 @@
+package flatfile
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//fieldLayout is the parsed flatfile tag of a single struct field
+type fieldLayout struct {
+	//tagged is false for fields without a flatfile tag
+	tagged bool
+	rawTag string
+	tag    flatfileTag
+	//err is the tag parse error, reported only when the field is reached during unmarshal
+	err error
+	//signIdx is the index of the field named by the signField option
+	signIdx int
+	//timeIdx is the index of the field named by the timeField option
+	timeIdx int
+	//name and fieldType are those of the struct field, cached so parsing a wide record does not copy each reflect.StructField
+	name      string
+	fieldType reflect.Type
+}
+
+//structLayout is the parsed flatfile tags of every field of a struct type
+type structLayout struct {
+	fields []fieldLayout
+	//allStrings is true when every tagged field is a plain string with only a column and length
+	//These structs are unmarshalled by slicing columns without the per field kind switch
+	allStrings bool
+	//lastTagged is the index of the tagged field with the greatest column or -1 if there are none
+	lastTagged int
+	//byCol is the indices of the tagged fields sorted by column so parsing does not depend on the order fields are declared
+	byCol []int
+}
+
+//layoutCache maps a reflect.Type to its *structLayout so tags are parsed once per type
+var layoutCache sync.Map
+
+//profileLayoutKey is the layoutCache key of the layout of a struct type under a named tag profile
+type profileLayoutKey struct {
+	t       reflect.Type
+	profile string
+}
+
+//cachedStructLayout returns the layout of struct type t, parsing its tags on first use
+func cachedStructLayout(t reflect.Type) *structLayout {
+	if layout, ok := layoutCache.Load(t); ok {
+		return layout.(*structLayout)
+	}
+	layout, _ := layoutCache.LoadOrStore(t, newStructLayout(t, nil, ""))
+	return layout.(*structLayout)
+}
+
+//cachedProfileLayout returns the layout of struct type t using the tags of the named profile
+func cachedProfileLayout(t reflect.Type, profile string) *structLayout {
+	if profile == "" {
+		return cachedStructLayout(t)
+	}
+	key := profileLayoutKey{t, profile}
+	if layout, ok := layoutCache.Load(key); ok {
+		return layout.(*structLayout)
+	}
+	layout, _ := layoutCache.LoadOrStore(key, newStructLayout(t, nil, profile))
+	return layout.(*structLayout)
+}
+
+//newStructLayout parses the tags of struct type t for the named profile, "" for the default
+//overrides replaces the tag of the fields it names
+func newStructLayout(t reflect.Type, overrides map[string]string, profile string) *structLayout {
+	layout := &structLayout{fields: make([]fieldLayout, t.NumField()), allStrings: true, lastTagged: -1}
+	//nextCol is the column after the previous tagged field where an auto field starts, or 0 when it cannot be known
+	nextCol := 1
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		layout.fields[i].name, layout.fields[i].fieldType = structField.Name, structField.Type
+		fieldTag, tagFlag := structField.Tag.Lookup("flatfile")
+		var profileErr error
+		if tagFlag {
+			fieldTag, profileErr = profileTag(fieldTag, profile)
+		}
+		if override, ok := overrides[structField.Name]; ok {
+			fieldTag, tagFlag, profileErr = override, true, nil
+		}
+		if !tagFlag || fieldTag == ignoreTag {
+			continue
+		}
+		field := &layout.fields[i]
+		field.tagged = true
+		layout.byCol = append(layout.byCol, i)
+		field.rawTag = fieldTag
+		field.err = profileErr
+		if field.err == nil {
+			field.err = parseFlatfileTag(fieldTag, &field.tag)
+		}
+		if field.err == nil && field.tag.autoCol {
+			if nextCol == 0 {
+				field.err = errors.Errorf("flatfile.newStructLayout: Field %s has an auto column but the previous tagged field has a variable length or invalid tag", structField.Name)
+			}
+			field.tag.col = nextCol
+		}
+		nextCol = nextColumn(structField.Type, field)
+		if field.err == nil && structField.PkgPath != "" {
+			field.err = errors.Errorf("flatfile.newStructLayout: Field %s is unexported and cannot be set", structField.Name)
+		}
+		if field.err == nil {
+			field.err = checkOccursKind(structField.Type, &field.tag)
+		}
+		if field.err == nil {
+			field.err = checkLenPrefixKind(structField.Type, &field.tag)
+		}
+		if field.err == nil {
+			field.err = checkTimeLayoutWidth(structField.Type, &field.tag)
+		}
+		if field.err == nil && field.tag.signField != "" {
+			field.signIdx, field.err = resolveFieldRef(t, field.tag.signField, "Sign")
+		}
+		if field.err == nil && field.tag.timeField != "" {
+			field.timeIdx, field.err = resolveFieldRef(t, field.tag.timeField, "Time")
+		}
+		if field.err != nil || !isPlainStringField(structField, &field.tag) {
+			layout.allStrings = false
+		}
+	}
+	sort.SliceStable(layout.byCol, func(a, b int) bool {
+		return layout.fields[layout.byCol[a]].tag.col < layout.fields[layout.byCol[b]].tag.col
+	})
+	if len(layout.byCol) > 0 {
+		layout.lastTagged = layout.byCol[len(layout.byCol)-1]
+	}
+	return layout
+}
+
+//firstColFrom returns the smallest column of the tagged fields from index i on, or 1 if there are none
+//The occursAt count of a field is included as it must be read before the field
+func (layout *structLayout) firstColFrom(i int) int {
+	firstCol := 0
+	for ; i < len(layout.fields); i++ {
+		field := &layout.fields[i]
+		if !field.tagged || field.err != nil {
+			continue
+		}
+		col := field.tag.col
+		if field.tag.occursCol > 0 && field.tag.occursCol < col {
+			col = field.tag.occursCol
+		}
+		if firstCol == 0 || col < firstCol {
+			firstCol = col
+		}
+	}
+	return max(firstCol, 1)
+}
+
+//nextColumn returns the column following field, or 0 if the field has a variable length or invalid tag
+func nextColumn(t reflect.Type, field *fieldLayout) int {
+	if field.err != nil || field.tag.occurs == greedyOccurs || field.tag.lenPrefix || field.tag.occursCol > 0 {
+		return 0
+	}
+	return field.tag.col + fieldWidth(t, &field.tag)
+}
+
+//stringType is the type of the builtin string. Named string types may have registered handling so are not plain
+var stringType = reflect.TypeOf("")
+
+//isPlainStringField returns true for exported string fields whose tag only sets column and length
+func isPlainStringField(structField reflect.StructField, ffpTag *flatfileTag) bool {
+	plainTag := flatfileTag{col: ffpTag.col, length: ffpTag.length, autoCol: ffpTag.autoCol}
+	return structField.Type == stringType && structField.PkgPath == "" && reflect.DeepEqual(*ffpTag, plainTag)
+}
+
+//recordLength returns the number of bytes a record of struct type t spans, from column 1 to the end of its furthest field
+func recordLength(t reflect.Type) (int, error) {
+	return cachedStructLayout(t).recordLength(t)
+}
+
+//recordLength returns the record length of struct type t using its layout
+func (layout *structLayout) recordLength(t reflect.Type) (int, error) {
+	recLength := 0
+	for i := range layout.fields {
+		field := &layout.fields[i]
+		if !field.tagged {
+			continue
+		}
+		if field.err != nil {
+			return 0, errors.Wrapf(field.err, "flatfile.recordLength: Field %s has invalid tag %s", t.Field(i).Name, field.rawTag)
+		}
+		if field.tag.occurs == greedyOccurs || field.tag.lenPrefix || field.tag.occursCol > 0 {
+			return 0, errors.Errorf("flatfile.recordLength: Field %s has a variable length so the record length of %s cannot be determined", t.Field(i).Name, t)
+		}
+		recLength = max(recLength, field.tag.col-1+fieldWidth(t.Field(i).Type, &field.tag))
+	}
+	return recLength, nil
+}
+
+//checkOccursKind rejects an occurs on a field that does not repeat, which would otherwise be silently ignored
+func checkOccursKind(t reflect.Type, ffpTag *flatfileTag) error {
+	if ffpTag.occurs == 0 && ffpTag.occursCol == 0 {
+		return nil
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		return errors.Errorf("flatfile.checkOccursKind: Occurs can only be used with a slice or array field not %s", t)
+	}
+	return nil
+}
+
+//checkLenPrefixKind rejects lenPrefix on a field other than a string or []byte, which would otherwise take the rest of the record
+func checkLenPrefixKind(t reflect.Type, ffpTag *flatfileTag) error {
+	if !ffpTag.lenPrefix {
+		return nil
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.String && (t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint8) {
+		return errors.Errorf("flatfile.checkLenPrefixKind: lenPrefix can only be used with a string or []byte field not %s", t)
+	}
+	return nil
+}
+
+//timeLayoutRefs are formatted to find the width of a time layout. They differ in the width of every variable width element
+//such as month and day names, unpadded numbers, fractional seconds with 9s and Z zones
+var timeLayoutRefs = [2]time.Time{
+	time.Date(2006, 1, 2, 3, 4, 5, 0, time.FixedZone("MST", -7*60*60)),
+	time.Date(2017, 11, 30, 10, 45, 59, 123456789, time.UTC),
+}
+
+//checkTimeLayoutWidth returns an error if the layout of a time field always formats to more or fewer bytes than the field
+//Layouts whose width depends on the time, such as January, cannot be checked until a time is marshalled
+func checkTimeLayoutWidth(t reflect.Type, ffpTag *flatfileTag) error {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Array || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if (t != timeType && t != nullTimeType) || ffpTag.layout == "" {
+		return nil
+	}
+	width := len(timeLayoutRefs[0].Format(ffpTag.layout))
+	if width != len(timeLayoutRefs[1].Format(ffpTag.layout)) || width == ffpTag.length {
+		return nil
+	}
+	return errors.Errorf("flatfile.checkTimeLayoutWidth: Time layout %s is %d bytes wide but the field is %d bytes", ffpTag.layout, width, ffpTag.length)
+}
+
+//resolveFieldRef returns the index of the tagged field of struct type t named by a signField or timeField option
+//The field may be declared before or after the field referencing it. role names the reference in errors
+func resolveFieldRef(t reflect.Type, name string, role string) (int, error) {
+	refField, exists := t.FieldByName(name)
+	if !exists || len(refField.Index) != 1 {
+		return 0, errors.Errorf("flatfile.resolveFieldRef: %s has no field %s", t, name)
+	}
+	refTag, tagFlag := refField.Tag.Lookup("flatfile")
+	if !tagFlag || refTag == ignoreTag {
+		return 0, errors.Errorf("flatfile.resolveFieldRef: %s field %s has no flatfile tag", role, name)
+	}
+	return refField.Index[0], nil
+}
@@ -0,0 +1,115 @@
+package flatfile
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type allStringsRecord struct {
+	Name        string `flatfile:"1,3"`
+	OpenDate    string `flatfile:"4,10"`
+	Age         string `flatfile:"14,3"`
+	Address     string `flatfile:"17,15"`
+	CountryCode string `flatfile:"32,2"`
+	Note        string
+}
+
+var allStringsData = []byte("AMY1900-01-01019123 FAKE STREETCA")
+
+func TestCachedStructLayout(t *testing.T) {
+	type MixedRecord struct {
+		Name string `flatfile:"1,3"`
+		Age  int    `flatfile:"4,2"`
+	}
+	type OptionRecord struct {
+		Names []string `flatfile:"1,3,2"`
+	}
+
+	var tests = []struct {
+		Type           reflect.Type
+		WantAllStrings bool
+	}{
+		{reflect.TypeOf(allStringsRecord{}), true},
+		{reflect.TypeOf(MixedRecord{}), false},
+		{reflect.TypeOf(OptionRecord{}), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Type.Name(), func(t *testing.T) {
+			layout := cachedStructLayout(tt.Type)
+			if layout.allStrings != tt.WantAllStrings {
+				t.Errorf("cachedStructLayout(%s).allStrings got: %v want: %v", tt.Type, layout.allStrings, tt.WantAllStrings)
+			}
+			if cachedStructLayout(tt.Type) != layout {
+				t.Errorf("cachedStructLayout(%s) should return the cached layout", tt.Type)
+			}
+		})
+	}
+}
+
+func TestAllStringsFastPath_Unmarshal(t *testing.T) {
+	fast := &allStringsRecord{}
+	generic := &allStringsRecord{}
+
+	if err := Unmarshal(allStringsData, fast, 0, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := unmarshal(allStringsData, generic, 0, 0, false, &unmarshalOptions{hasOptions: true}); err != nil {
+		t.Fatal(err)
+	}
+	if *fast != *generic {
+		t.Errorf("all string fast path got: %v generic path got: %v", *fast, *generic)
+	}
+	if fast.Address != "123 FAKE STREET" || fast.CountryCode != "CA" {
+		t.Errorf("Unexpected results %v", *fast)
+	}
+}
+
+func BenchmarkUnmarshalAllStrings(b *testing.B) {
+	record := &allStringsRecord{}
+	for i := 0; i < b.N; i++ {
+		if err := Unmarshal(allStringsData, record, 0, 0, false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalAllStringsGeneric(b *testing.B) {
+	record := &allStringsRecord{}
+	o := &unmarshalOptions{hasOptions: true}
+	for i := 0; i < b.N; i++ {
+		if err := unmarshal(allStringsData, record, 0, 0, false, o); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+//wideRecord builds a struct type of n fields alternating int and string, each 5 bytes wide, and a record of data for it
+func wideRecord(n int) (reflect.Type, []byte) {
+	fields := make([]reflect.StructField, n)
+	data := make([]byte, 0, n*5)
+	for i := range fields {
+		fields[i] = reflect.StructField{
+			Name: fmt.Sprintf("Field%d", i),
+			Type: reflect.TypeOf(0),
+			Tag:  reflect.StructTag(fmt.Sprintf(`flatfile:"%d,5"`, i*5+1)),
+		}
+		if i%2 == 1 {
+			fields[i].Type = reflect.TypeOf("")
+		}
+		data = append(data, fmt.Sprintf("%05d", i)...)
+	}
+	return reflect.StructOf(fields), data
+}
+
+func BenchmarkUnmarshalWide(b *testing.B) {
+	wideType, data := wideRecord(300)
+	record := reflect.New(wideType).Interface()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		if err := Unmarshal(data, record, 0, 0, false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,499 @@
+package flatfile
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//FieldMarshaler is implemented by types that marshal their own field data, the counterpart of FieldUnmarshaler
+//The bytes returned are written at the column of the field and padded with spaces to its length
+type FieldMarshaler interface {
+	MarshalFlatfileField() ([]byte, error)
+}
+
+var fieldMarshalerType = reflect.TypeOf((*FieldMarshaler)(nil)).Elem()
+
+//Marshal writes v, a struct or pointer to a struct, as a fixed-width record using the same flatfile tags as Unmarshal
+//Each field is written at its column so fields may be declared in any order. Bytes not covered by a field are spaces
+//Overlapping fields are written in column order, fields at the same column in the order they are declared, and a later
+//field overwrites the bytes of an earlier one. Strings, bools and other left justified values only write their own bytes
+//so a shorter value, or a blank one, leaves the rest of an earlier field in place, while numbers pad their whole field
+//The record is as long as the end of its furthest field. Variable length fields such as a greedy occurs cannot be marshalled
+//Values are written as follows:
+//	Strings are left justified and padded with spaces
+//	Numbers are right justified and padded with spaces e.g. 42 in 5 bytes is "   42" and -42 is "  -42", or with zeros after
+//	the sign with WithZeroPad e.g. -42 is "-0042"
+//	Floats are written with the decimals they need, or rounded to the decimals option of the tag by WithRoundingMode
+//	Percentages are written with the implied decimals of the percent option and padded with zeros e.g. 2.55 with percent=3 in 5 bytes is 02550
+//	Bools are T or F, 1 or 0 with boolmode=numeric, the true and false values of the tag, or the text given by WithBoolText
+//	Times are formatted with the layout of the tag, a zero time is left blank unless WithZeroTimeFill is given
+//	Registered enums are written as their code and a nil pointer is left blank
+//	Nested structs are written within their field
+//	Arrays and slices write each element, a slice shorter than its occurs leaves the remaining elements blank unless
+//	WithStrictOccurs is given
+//A field tagged blankzero is left blank when its value is the zero value of its type, e.g. 0 rather than being written as 0
+//A value too long for its field is an error rather than being truncated, unless WithOverflowPolicy allows it for numbers
+//Fields of a tag with an option that only applies when reading, such as conv, regex or money, return an error
+//Conditional fields are written after the others, and only when their condition holds for the record written so far
+//opts: WithEncoding encodes fields as it decodes them for Unmarshal, WithProfile and WithTagOverrides select the layout written
+//WithZeroTimeFill sets what a zero time is written as and WithOverflowPolicy what a number too long for its field is written as
+//WithZeroPad pads numbers with zeros rather than spaces
+//An Option that only applies to Unmarshal, such as WithLimit, is an error
+func Marshal(v interface{}, opts ...Option) ([]byte, error) {
+	o := newUnmarshalOptions(opts)
+	if option := o.marshalUnsupported(); option != "" {
+		return nil, errors.Errorf("flatfile.Marshal: %s only applies to Unmarshal", option)
+	}
+	vValue := reflect.ValueOf(v)
+	if vValue.Kind() == reflect.Ptr && !vValue.IsNil() {
+		vValue = vValue.Elem()
+	}
+	if vValue.Kind() != reflect.Struct {
+		return nil, errors.Errorf("flatfile.Marshal: Expected a struct or pointer to a struct but got %v", reflect.TypeOf(v))
+	}
+	if !vValue.CanAddr() {
+		//fields must be addressable for types whose pointer implements FieldMarshaler
+		addressable := reflect.New(vValue.Type()).Elem()
+		addressable.Set(vValue)
+		vValue = addressable
+	}
+	layout, err := o.structLayout(vValue.Type())
+	if err != nil {
+		return nil, err
+	}
+	recLength, err := layout.recordLength(vValue.Type())
+	if err != nil {
+		return nil, errors.Wrap(err, "flatfile.Marshal: Cannot determine the record length")
+	}
+	padding := byte(' ')
+	if o.encoding != nil {
+		padding = o.encoding.space()
+	}
+	record := bytes.Repeat([]byte{padding}, recLength)
+	if err := marshalStruct(record, vValue, o); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+//WithZeroTimeFill makes Marshal write a zero time.Time as fill repeated across the field, e.g. '0' for 00000000
+//By default a zero time is left blank
+func WithZeroTimeFill(fill byte) Option {
+	return func(o *unmarshalOptions) {
+		o.zeroTimeFill = fill
+	}
+}
+
+//WithZeroPad makes Marshal pad numbers with zeros after their sign rather than spaces before it, as legacy signed numeric
+//fields expect e.g. -1234 in 8 bytes is -0001234 rather than "   -1234"
+func WithZeroPad() Option {
+	return func(o *unmarshalOptions) {
+		o.zeroPad = true
+	}
+}
+
+//WithStrictOccurs makes a slice with fewer elements than its occurs an error for Marshal
+//By default the elements it does not have are left blank
+func WithStrictOccurs() Option {
+	return func(o *unmarshalOptions) {
+		o.strictOccurs = true
+	}
+}
+
+//OverflowPolicy is what Marshal does with a number longer than its field
+type OverflowPolicy int
+
+const (
+	//OverflowError returns an error, the default
+	OverflowError OverflowPolicy = iota
+	//OverflowTruncateLeft drops the high order digits that do not fit e.g. 123456 in 4 bytes is 3456
+	OverflowTruncateLeft
+	//OverflowTruncateRight drops the low order digits that do not fit e.g. 123456 in 4 bytes is 1234
+	OverflowTruncateRight
+	//OverflowSaturate writes the largest number of the same sign that fits e.g. 123456 in 4 bytes is 9999 and -123456 is -999
+	OverflowSaturate
+)
+
+//WithOverflowPolicy sets what Marshal does with a number longer than its field, OverflowError by default
+//Other than with OverflowError the decimals of a float are dropped first e.g. 12.345 in 4 bytes is 12.3 and 123456.7 is
+//truncated or saturated as 123456
+//Strings, times and other fields that are too long are always an error
+func WithOverflowPolicy(policy OverflowPolicy) Option {
+	return func(o *unmarshalOptions) {
+		o.overflow = policy
+	}
+}
+
+//RoundingMode is how Marshal rounds a float field with more decimals than its decimals option
+type RoundingMode int
+
+const (
+	//RoundHalfUp rounds halves away from zero e.g. 2.345 to 2 decimals is 2.35 and -2.345 is -2.35, the default
+	RoundHalfUp RoundingMode = iota
+	//RoundHalfEven rounds halves to the even digit, banker's rounding e.g. 2.345 is 2.34 and 2.355 is 2.36
+	RoundHalfEven
+	//RoundTruncate drops the extra decimals e.g. 2.349 is 2.34
+	RoundTruncate
+)
+
+//WithRoundingMode sets how Marshal rounds float fields with a decimals option, RoundHalfUp by default
+//Rounding is applied to the shortest decimal text of the float so 2.345 is a half even though the float is slightly less
+func WithRoundingMode(mode RoundingMode) Option {
+	return func(o *unmarshalOptions) {
+		o.rounding = mode
+	}
+}
+
+//marshalStruct writes each tagged field of vStruct into record at its column
+func marshalStruct(record []byte, vStruct reflect.Value, o *unmarshalOptions) error {
+	layout, err := o.structLayout(vStruct.Type())
+	if err != nil {
+		return err
+	}
+	//tags are overridden for the top level struct only, nested structs are written with their own tags
+	fieldOpts := o
+	if o.overrides != nil {
+		withoutOverrides := *o
+		withoutOverrides.overrides = nil
+		fieldOpts = &withoutOverrides
+	}
+	//conditional fields are written last so their condition can be checked against the other fields
+	for _, conditional := range []bool{false, true} {
+		for _, i := range layout.byCol {
+			field := &layout.fields[i]
+			if field.err != nil {
+				return errors.Wrapf(field.err, "flatfile.Marshal: Field %s has invalid tag %s", field.name, field.rawTag)
+			}
+			ffpTag := &field.tag
+			if ffpTag.condChk != conditional || (conditional && !ShouldUnmarshal(ffpTag, textRecord(record, o.encoding))) {
+				continue
+			}
+			lowerBound := ffpTag.col - 1
+			upperBound := lowerBound + fieldWidth(field.fieldType, ffpTag)
+			if upperBound > len(record) {
+				return errors.Errorf("flatfile.Marshal: Field %s ends at col %d after the %d bytes of the record", field.name, upperBound, len(record))
+			}
+			if err := marshalField(record[lowerBound:upperBound], vStruct.Field(i), ffpTag, fieldOpts); err != nil {
+				return errors.Wrapf(err, "flatfile.Marshal: Failed to marshal field %s col %d len %d", field.name, ffpTag.col, ffpTag.length)
+			}
+		}
+	}
+	return nil
+}
+
+//marshalField writes the value of field into fieldData, which is blank and exactly as wide as the field
+func marshalField(fieldData []byte, field reflect.Value, ffpTag *flatfileTag, o *unmarshalOptions) error {
+	if option := unmarshalOnlyOption(ffpTag); option != "" {
+		return errors.Errorf("flatfile.marshalField: Fields using the %s option cannot be marshalled", option)
+	}
+	t := field.Type()
+	enc := o.encoding
+	//strings are encoded with enc. Encodings that do not share ASCII, such as EBCDIC, encode every text field
+	var stringEnc, textEnc *Encoding
+	if enc != nil && isTextField(t, ffpTag) {
+		stringEnc = enc
+		if !enc.ascii {
+			textEnc = enc
+		}
+	}
+	if ffpTag.constChk {
+		return putLeft(fieldData, []byte(ffpTag.constVal), textEnc)
+	}
+	if ffpTag.blankZero && isZeroValue(field) {
+		return nil
+	}
+	if field.CanAddr() && reflect.PtrTo(t).Implements(fieldMarshalerType) {
+		value, err := field.Addr().Interface().(FieldMarshaler).MarshalFlatfileField()
+		if err != nil {
+			return errors.Wrap(err, "flatfile.marshalField: MarshalFlatfileField failed")
+		}
+		return putLeft(fieldData, value, nil)
+	}
+	if codes, ok := lookupEnum(t); ok {
+		return marshalEnum(fieldData, field, codes, textEnc)
+	}
+	if t == timeType {
+		if value := field.Interface().(time.Time); !value.IsZero() {
+			return putLeft(fieldData, []byte(value.Format(ffpTag.layout)), textEnc)
+		}
+		if o.zeroTimeFill != 0 {
+			return putLeft(fieldData, bytes.Repeat([]byte{o.zeroTimeFill}, len(fieldData)), textEnc)
+		}
+		return nil
+	}
+	if isSQLNullType(t) {
+		return errors.Errorf("flatfile.marshalField: %s fields cannot be marshalled", t)
+	}
+	switch field.Kind() {
+	case reflect.String:
+		return putLeft(fieldData, []byte(field.String()), stringEnc)
+	case reflect.Bool:
+		return putLeft(fieldData, []byte(boolText(field.Bool(), ffpTag, o)), textEnc)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if ffpTag.override == "rune" {
+			return putLeft(fieldData, []byte(string(rune(field.Int()))), textEnc)
+		}
+		return putNumber(fieldData, strconv.FormatInt(field.Int(), 10), o.zeroPad, textEnc, o)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if ffpTag.override == "byte" {
+			return putLeft(fieldData, []byte{byte(field.Uint())}, nil)
+		}
+		return putNumber(fieldData, strconv.FormatUint(field.Uint(), 10), o.zeroPad, textEnc, o)
+	case reflect.Float32, reflect.Float64:
+		number := strconv.FormatFloat(field.Float(), 'f', -1, t.Bits())
+		var err error
+		switch {
+		case ffpTag.percentChk:
+			//a percentage is written with its implied decimals and no decimal point, the reverse of assignPercent
+			number, err = roundDecimal(shiftDecimal(number, impliedPercentDecimals(ffpTag)), 0, o.rounding)
+		case ffpTag.decimalsChk:
+			number, err = roundDecimal(number, ffpTag.decimals, o.rounding)
+		}
+		if err != nil {
+			return err
+		}
+		return putNumber(fieldData, number, o.zeroPad || ffpTag.percentChk, textEnc, o)
+	case reflect.Ptr:
+		if field.IsNil() {
+			return nil
+		}
+		return marshalField(fieldData, field.Elem(), ffpTag, o)
+	case reflect.Struct:
+		if !isNestedStruct(t) {
+			return errors.Errorf("flatfile.marshalField: %s fields cannot be marshalled", t)
+		}
+		return marshalStruct(fieldData, field, o)
+	case reflect.Array, reflect.Slice:
+		return marshalElements(fieldData, field, ffpTag, o)
+	}
+	return errors.Errorf("flatfile.marshalField: Unsupported kind %s of field type %s", field.Kind(), t)
+}
+
+//marshalElements writes each element of an array or slice field into its place in fieldData
+func marshalElements(fieldData []byte, field reflect.Value, ffpTag *flatfileTag, o *unmarshalOptions) error {
+	t := field.Type()
+	if isRuneSequence(t, ffpTag) && (t.Kind() == reflect.Array || ffpTag.occurs == 0) {
+		runes := make([]rune, field.Len())
+		for i := range runes {
+			runes[i] = rune(field.Index(i).Int())
+		}
+		//unused elements of a rune array are zero
+		var textEnc *Encoding
+		if o.encoding != nil && !o.encoding.ascii {
+			textEnc = o.encoding
+		}
+		return putLeft(fieldData, []byte(strings.TrimRight(string(runes), "\x00")), textEnc)
+	}
+	count := ffpTag.occurs
+	if t.Kind() == reflect.Array {
+		count = t.Len()
+	}
+	if count == 0 {
+		return errors.Errorf("flatfile.marshalField: Occurs clause must be provided when using slice. `flatfile:\"col,len,occurs\"`")
+	}
+	if field.Len() > count {
+		return errors.Errorf("flatfile.marshalField: Slice of %d elements is longer than its occurs of %d", field.Len(), count)
+	}
+	if o.strictOccurs && field.Len() < count {
+		return errors.Errorf("flatfile.marshalField: Slice of %d elements is shorter than its occurs of %d", field.Len(), count)
+	}
+	elemWidth := elementWidth(t.Elem(), ffpTag)
+	for i := 0; i < field.Len(); i++ {
+		lowerBound := i * elemWidth
+		if err := marshalField(fieldData[lowerBound:lowerBound+elemWidth], field.Index(i), elementTag(t.Elem(), ffpTag, lowerBound), o); err != nil {
+			return errors.Wrapf(err, "flatfile.marshalField: Failed to marshal element %d at col %d", i, ffpTag.col+lowerBound)
+		}
+	}
+	return nil
+}
+
+//marshalEnum writes the code registered for the value of field, the first in sorted order if several share the value
+func marshalEnum(fieldData []byte, field reflect.Value, codes map[string]reflect.Value, enc *Encoding) error {
+	var matches []string
+	for code, value := range codes {
+		if value.Interface() == field.Interface() {
+			matches = append(matches, code)
+		}
+	}
+	if len(matches) == 0 {
+		return errors.Errorf("flatfile.marshalEnum: %v has no registered code", field.Interface())
+	}
+	sort.Strings(matches)
+	return putLeft(fieldData, []byte(matches[0]), enc)
+}
+
+//isZeroValue returns true if field holds the zero value of its type. A time is zero if it is the zero instant in any location
+func isZeroValue(field reflect.Value) bool {
+	if field.Type() == timeType {
+		return field.Interface().(time.Time).IsZero()
+	}
+	return reflect.DeepEqual(field.Interface(), reflect.Zero(field.Type()).Interface())
+}
+
+//boolText returns the text of a bool field as Unmarshal reads it
+func boolText(value bool, ffpTag *flatfileTag, o *unmarshalOptions) string {
+	switch {
+	case ffpTag.trueVal != "" || ffpTag.falseVal != "":
+		if value {
+			return ffpTag.trueVal
+		}
+		return ffpTag.falseVal
+	case ffpTag.boolNumeric && value:
+		return "1"
+	case ffpTag.boolNumeric:
+		return "0"
+	case o.trueText != "" || o.falseText != "":
+		if value {
+			return o.trueText
+		}
+		return o.falseText
+	case value:
+		return "T"
+	}
+	return "F"
+}
+
+//marshalUnsupported returns the name of an Option given to Marshal that only applies to Unmarshal, or "" if there is none
+func (o *unmarshalOptions) marshalUnsupported() string {
+	switch {
+	case o.zeroFirst:
+		return "WithZeroFirst"
+	case o.encodingField != "":
+		return "WithEncodingField"
+	case o.partialLastField:
+		return "WithPartialLastField"
+	case o.limit != 0:
+		return "WithLimit"
+	case o.separator != "":
+		return "WithSeparator"
+	case o.maxRecords != 0:
+		return "WithMaxRecords"
+	case o.strictKinds:
+		return "WithStrictKinds"
+	case o.strictNumeric:
+		return "WithStrictNumeric"
+	case o.strictLength:
+		return "WithStrictLength"
+	case o.lenient:
+		return "WithLenientValues"
+	case o.normalize != nil:
+		return "WithNormalize"
+	}
+	return ""
+}
+
+//unmarshalUnsupported returns the name of an Option given to Unmarshal that only applies to Marshal, or "" if there is none
+func (o *unmarshalOptions) unmarshalUnsupported() string {
+	switch {
+	case o.zeroTimeFill != 0:
+		return "WithZeroTimeFill"
+	case o.zeroPad:
+		return "WithZeroPad"
+	case o.strictOccurs:
+		return "WithStrictOccurs"
+	case o.overflow != OverflowError:
+		return "WithOverflowPolicy"
+	case o.rounding != RoundHalfUp:
+		return "WithRoundingMode"
+	}
+	return ""
+}
+
+//unmarshalOnlyOption returns the name of an option of ffpTag that only applies when reading, or "" if there is none
+func unmarshalOnlyOption(ffpTag *flatfileTag) string {
+	switch {
+	case ffpTag.conv != "":
+		return "conv"
+	case ffpTag.regex != nil:
+		return "regex"
+	case ffpTag.money:
+		return "money"
+	case ffpTag.paren:
+		return "paren"
+	case ffpTag.bitFlags:
+		return "bitflags"
+	case ffpTag.zoned:
+		return "zoned"
+	case ffpTag.packed:
+		return "packed"
+	case ffpTag.runLenChk:
+		return "runlen"
+	case ffpTag.lenPrefix:
+		return "lenPrefix"
+	case ffpTag.occursCol > 0:
+		return "occursAt"
+	case ffpTag.occurs == greedyOccurs:
+		return "greedy occurs"
+	case ffpTag.signField != "":
+		return "signField"
+	case ffpTag.timeField != "":
+		return "timeField"
+	}
+	return ""
+}
+
+//putLeft copies value, encoded with enc if not nil, to the start of fieldData, leaving the rest of fieldData as padding
+func putLeft(fieldData []byte, value []byte, enc *Encoding) error {
+	if enc != nil {
+		encoded, err := enc.encode(value)
+		if err != nil {
+			return errors.Wrap(err, "flatfile.putLeft: Failed to encode value")
+		}
+		value = encoded
+	}
+	if len(value) > len(fieldData) {
+		return errors.Errorf("flatfile.putLeft: Value %q is longer than the %d bytes of the field", value, len(fieldData))
+	}
+	copy(fieldData, value)
+	return nil
+}
+
+//putNumber right justifies number in fieldData, padding with spaces before it or with zeros after its sign if zeroPad
+//A number longer than fieldData is handled by the overflow policy of o. The digits are encoded with enc if not nil
+func putNumber(fieldData []byte, number string, zeroPad bool, enc *Encoding, o *unmarshalOptions) error {
+	sign := ""
+	if strings.HasPrefix(number, "-") {
+		sign, number = "-", number[1:]
+	}
+	if width := len(fieldData) - len(sign); len(number) > width {
+		if width < 1 || o.overflow == OverflowError {
+			return errors.Errorf("flatfile.putNumber: Value %s%s is longer than the %d bytes of the field", sign, number, len(fieldData))
+		}
+		//the decimals of a float are dropped before any of its integer digits
+		if point := strings.IndexByte(number, '.'); point >= 0 {
+			number = strings.TrimSuffix(number[:max(width, point)], ".")
+		}
+	}
+	if width := len(fieldData) - len(sign); len(number) > width {
+		switch o.overflow {
+		case OverflowTruncateLeft:
+			number = number[len(number)-width:]
+		case OverflowTruncateRight:
+			number = number[:width]
+		case OverflowSaturate:
+			number = strings.Repeat("9", width)
+		default:
+			return errors.Errorf("flatfile.putNumber: Unknown overflow policy %d", o.overflow)
+		}
+	}
+	if zeroPad {
+		number = sign + strings.Repeat("0", len(fieldData)-len(sign)-len(number)) + number
+	} else {
+		number = strings.Repeat(" ", len(fieldData)-len(sign)-len(number)) + sign + number
+	}
+	copy(fieldData, number)
+	if enc != nil {
+		encoded, err := enc.encode(fieldData)
+		if err != nil {
+			return errors.Wrap(err, "flatfile.putNumber: Failed to encode value")
+		}
+		copy(fieldData, encoded)
+	}
+	return nil
+}
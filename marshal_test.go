@@ -0,0 +1,635 @@
+package flatfile
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testMarshalAddress struct {
+	Street string `flatfile:"1,10"`
+	Unit   uint8  `flatfile:"11,2"`
+}
+
+type testMarshalRecord struct {
+	//fields are declared out of column order as Marshal writes each field at its column
+	Amount   float64            `flatfile:"11,7"`
+	Name     string             `flatfile:"1,10"`
+	Count    int16              `flatfile:"18,4"`
+	Active   bool               `flatfile:"22,1"`
+	Opened   time.Time          `flatfile:"23,8,layout=20060102"`
+	Address  testMarshalAddress `flatfile:"31,12"`
+	Scores   [3]int             `flatfile:"43,2"`
+	Codes    []string           `flatfile:"49,3,2"`
+	Optional *int               `flatfile:"55,3"`
+	Initial  byte               `flatfile:"58,1,,override=byte"`
+	Flag     bool               `flatfile:"59,1,true=Y,false=N"`
+}
+
+func TestMarshal(t *testing.T) {
+	optional := 7
+	record := testMarshalRecord{
+		Amount:   -12.5,
+		Name:     "AMY       ",
+		Count:    42,
+		Active:   true,
+		Opened:   time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC),
+		Address:  testMarshalAddress{Street: "MAIN ST   ", Unit: 4},
+		Scores:   [3]int{1, 22, 3},
+		Codes:    []string{"AB ", "CD "},
+		Optional: &optional,
+		Initial:  'Z',
+		Flag:     false,
+	}
+	want := "AMY         -12.5  42T20200131MAIN ST    4 122 3AB CD   7ZN"
+
+	got, err := Marshal(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("Marshal got: %q want: %q", got, want)
+	}
+
+	roundTrip := testMarshalRecord{}
+	if err := Unmarshal(got, &roundTrip, 0, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(roundTrip, record) {
+		t.Errorf("Unmarshal(Marshal) got: %+v want: %+v", roundTrip, record)
+	}
+
+	//blank padding for nil pointers, zero times and a slice shorter than its occurs
+	if got, err := Marshal(&testMarshalRecord{Codes: []string{"AB"}}); err != nil || string(got[22:30]) != "        " || string(got[48:55]) != "AB     " {
+		t.Errorf("Marshal of blank fields got: %q err: %v", got, err)
+	}
+}
+
+func TestMarshalConditional(t *testing.T) {
+	type FfpTest struct {
+		Type   string `flatfile:"1,1"`
+		Name   string `flatfile:"2,5,condition=1-1-N"`
+		Amount int    `flatfile:"2,5,condition=1-1-A"`
+	}
+
+	var tests = []struct {
+		Record FfpTest
+		Want   string
+	}{
+		{FfpTest{Type: "N", Name: "AMY", Amount: 9}, "NAMY  "},
+		{FfpTest{Type: "A", Name: "AMY", Amount: 9}, "A    9"},
+		{FfpTest{Type: "X", Name: "AMY", Amount: 9}, "X     "},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestMarshalConditional-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, err := Marshal(&tt.Record)
+			if err != nil || string(got) != tt.Want {
+				t.Errorf("Marshal(%+v) got: %q err: %v want: %q", tt.Record, got, err, tt.Want)
+			}
+		})
+	}
+}
+
+type testMarshalPostal struct {
+	Code    string `flatfile:"1,3"`
+	Country string `flatfile:"4,2"`
+}
+
+type testMarshalLocation struct {
+	Street string             `flatfile:"1,6"`
+	Postal testMarshalPostal  `flatfile:"7,5"`
+	Prev   *testMarshalPostal `flatfile:"12,5"`
+}
+
+type testMarshalPerson struct {
+	Home testMarshalLocation `flatfile:"11,16"`
+	Name string              `flatfile:"1,10"`
+	Age  int                 `flatfile:"27,3"`
+}
+
+func TestMarshalNested(t *testing.T) {
+	//nested columns are relative to the parent field, two levels deep
+	var tests = []struct {
+		Record testMarshalPerson
+		Want   string
+	}{
+		{testMarshalPerson{
+			Home: testMarshalLocation{Street: "MAIN  ", Postal: testMarshalPostal{"M5V", "CA"}, Prev: &testMarshalPostal{"K1A", "CA"}},
+			Name: "AMY       ",
+			Age:  42,
+		}, "AMY       MAIN  M5VCAK1ACA 42"},
+		{testMarshalPerson{
+			Home: testMarshalLocation{Street: "ELM   ", Postal: testMarshalPostal{"H2X", "CA"}, Prev: &testMarshalPostal{"H2X", "US"}},
+			Name: "BOB       ",
+		}, "BOB       ELM   H2XCAH2XUS  0"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestMarshalNested-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, err := Marshal(&tt.Record)
+			if err != nil || string(got) != tt.Want {
+				t.Fatalf("Marshal(%+v) got: %q err: %v want: %q", tt.Record, got, err, tt.Want)
+			}
+			roundTrip := testMarshalPerson{}
+			if err := Unmarshal(got, &roundTrip, 0, 0, false); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(roundTrip, tt.Record) {
+				t.Errorf("Unmarshal(Marshal) got: %+v want: %+v", roundTrip, tt.Record)
+			}
+		})
+	}
+}
+
+type testMarshalGrade int
+
+func TestMarshalEnum(t *testing.T) {
+	if err := RegisterEnum(reflect.TypeOf(testMarshalGrade(0)), map[string]interface{}{"A": 1, "B": 2, "TOP": 1}); err != nil {
+		t.Fatal(err)
+	}
+	type FfpTest struct {
+		Grade testMarshalGrade `flatfile:"1,3"`
+	}
+	if got, err := Marshal(FfpTest{Grade: 1}); err != nil || string(got) != "A  " {
+		t.Errorf("Marshal enum got: %q err: %v want: %q", got, err, "A  ")
+	}
+	if _, err := Marshal(FfpTest{Grade: 3}); err == nil || !strings.Contains(err.Error(), "3 has no registered code") {
+		t.Errorf("Marshal enum err: %v want message containing: 3 has no registered code", err)
+	}
+}
+
+func TestMarshalOptions(t *testing.T) {
+	type Inner struct {
+		Code string `flatfile:"1,2;v2=2,2"`
+	}
+	type FfpTest struct {
+		Name  string `flatfile:"1,3;v2=3,3"`
+		Count int    `flatfile:"4,2;v2=1,2"`
+		Inner Inner  `flatfile:"6,2;v2=6,3"`
+	}
+	record := FfpTest{Name: "AMY", Count: 7, Inner: Inner{Code: "AB"}}
+
+	var tests = []struct {
+		Opts []Option
+		Want string
+	}{
+		{nil, "AMY 7AB"},
+		{[]Option{WithProfile("v2")}, " 7AMY AB"},
+		//overrides apply to the top level struct only, as in Unmarshal
+		{[]Option{WithTagOverrides(map[string]string{"Name": "3,3", "Count": "1,2"})}, " 7AMYAB"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestMarshalOptions-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, err := Marshal(record, tt.Opts...)
+			if err != nil || string(got) != tt.Want {
+				t.Errorf("Marshal got: %q err: %v want: %q", got, err, tt.Want)
+			}
+			roundTrip := FfpTest{}
+			if err := Unmarshal(got, &roundTrip, 0, 0, false, tt.Opts...); err != nil || roundTrip != record {
+				t.Errorf("Unmarshal(Marshal) got: %+v err: %v want: %+v", roundTrip, err, record)
+			}
+		})
+	}
+
+	for _, opt := range []Option{WithLimit(3), WithSeparator("|"), WithStrictNumeric(), WithZeroFirst()} {
+		if _, err := Marshal(record, opt); err == nil || !strings.Contains(err.Error(), "only applies to Unmarshal") {
+			t.Errorf("Marshal err: %v want message containing: only applies to Unmarshal", err)
+		}
+	}
+	if _, err := Marshal(record, WithTagOverrides(map[string]string{"Missing": "1,1"})); err == nil {
+		t.Error("Marshal should return an error for an override of a missing field")
+	}
+}
+
+func TestMarshalZeroTime(t *testing.T) {
+	type timeRecord struct {
+		Opened time.Time  `flatfile:"1,8,layout=20060102"`
+		Closed *time.Time `flatfile:"9,6,layout=060102"`
+	}
+	closed := time.Time{}
+
+	var tests = []struct {
+		Opts []Option
+		Want string
+	}{
+		{nil, "              "},
+		{[]Option{WithZeroTimeFill('0')}, "00000000000000"},
+		{[]Option{WithZeroTimeFill('0'), WithEncoding(CP037)}, strings.Repeat("\xf0", 14)},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestMarshalZeroTime-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, err := Marshal(&timeRecord{Closed: &closed}, tt.Opts...)
+			if err != nil || string(got) != tt.Want {
+				t.Errorf("Marshal got: %q err: %v want: %q", got, err, tt.Want)
+			}
+		})
+	}
+
+	if err := Unmarshal([]byte("20200131"), &timeRecord{}, 0, 0, false, WithZeroTimeFill('0')); err == nil || !strings.Contains(err.Error(), "WithZeroTimeFill only applies to Marshal") {
+		t.Errorf("Unmarshal err: %v want message containing: WithZeroTimeFill only applies to Marshal", err)
+	}
+}
+
+func TestMarshalZeroPad(t *testing.T) {
+	type padRecord struct {
+		Balance int     `flatfile:"1,8"`
+		Count   uint    `flatfile:"9,3"`
+		Amount  float64 `flatfile:"12,7,decimals=2"`
+	}
+
+	var tests = []struct {
+		V         padRecord
+		Opts      []Option
+		Want      string
+		RoundTrip bool
+	}{
+		{padRecord{-1234, 7, -12.5}, nil, "   -1234  7 -12.50", true},
+		//the sign is written before the zeros
+		{padRecord{-1234, 7, -12.5}, []Option{WithZeroPad()}, "-0001234007-012.50", true},
+		{padRecord{1234, 0, 0}, []Option{WithZeroPad()}, "000012340000000.00", true},
+		{padRecord{-123456789, 7, 0}, []Option{WithZeroPad(), WithOverflowPolicy(OverflowSaturate)}, "-99999990070000.00", false},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestMarshalZeroPad-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, err := Marshal(&tt.V, tt.Opts...)
+			if err != nil || string(got) != tt.Want {
+				t.Errorf("Marshal(%+v) got: %q err: %v want: %q", tt.V, got, err, tt.Want)
+			}
+			var back padRecord
+			if err := Unmarshal(got, &back, 0, 0, false); tt.RoundTrip && (err != nil || back != tt.V) {
+				t.Errorf("Unmarshal(%q) got: %+v err: %v want: %+v", got, back, err, tt.V)
+			}
+		})
+	}
+
+	if err := Unmarshal([]byte("-0001234"), &padRecord{}, 0, 0, false, WithZeroPad()); err == nil || !strings.Contains(err.Error(), "WithZeroPad only applies to Marshal") {
+		t.Errorf("Unmarshal err: %v want message containing: WithZeroPad only applies to Marshal", err)
+	}
+}
+
+func TestMarshalBoolText(t *testing.T) {
+	type boolRecord struct {
+		Plain   bool `flatfile:"1,5"`
+		Mapped  bool `flatfile:"6,1,true=Y,false=N"`
+		Numeric bool `flatfile:"7,1,boolmode=numeric"`
+	}
+
+	var tests = []struct {
+		V    boolRecord
+		Opts []Option
+		Want string
+	}{
+		{boolRecord{true, true, true}, nil, "T    Y1"},
+		{boolRecord{false, false, false}, nil, "F    N0"},
+		//the tag mapping and boolmode take precedence over WithBoolText
+		{boolRecord{true, false, true}, []Option{WithBoolText("Y", "N")}, "Y    N1"},
+		{boolRecord{false, true, false}, []Option{WithBoolText("1", "0")}, "0    Y0"},
+		{boolRecord{true, true, false}, []Option{WithBoolText("true", "false")}, "true Y0"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestMarshalBoolText-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, err := Marshal(&tt.V, tt.Opts...)
+			if err != nil || string(got) != tt.Want {
+				t.Errorf("Marshal(%+v) got: %q err: %v want: %q", tt.V, got, err, tt.Want)
+			}
+			var back boolRecord
+			if err := Unmarshal(got, &back, 0, 0, false, tt.Opts...); err != nil || back != tt.V {
+				t.Errorf("Unmarshal(%q) got: %+v err: %v want: %+v", got, back, err, tt.V)
+			}
+		})
+	}
+
+	if err := Unmarshal([]byte("T    Y1"), &boolRecord{}, 0, 0, false, WithBoolText("Y", "N")); err == nil || !strings.Contains(err.Error(), `"T" is neither the true value "Y" nor the false value "N"`) {
+		t.Errorf("Unmarshal err: %v want message containing: \"T\" is neither the true value \"Y\" nor the false value \"N\"", err)
+	}
+}
+
+func TestMarshalBlankZero(t *testing.T) {
+	type blankRecord struct {
+		Amount int       `flatfile:"1,4,,blankzero"`
+		Rate   *float64  `flatfile:"5,4,,blankzero"`
+		Active bool      `flatfile:"9,1,,blankzero"`
+		Opened time.Time `flatfile:"10,6,layout=060102,blankzero"`
+		Closed time.Time `flatfile:"16,6,layout=060102"`
+		Count  int       `flatfile:"22,2"`
+	}
+	rate := 0.0
+
+	var tests = []struct {
+		V    blankRecord
+		Want string
+	}{
+		{blankRecord{Rate: &rate}, "               000000 0"},
+		{blankRecord{Amount: 12, Rate: &rate, Active: true, Opened: time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC)}, "  12    T200131000000 0"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestMarshalBlankZero-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, err := Marshal(&tt.V, WithZeroTimeFill('0'))
+			if err != nil || string(got) != tt.Want {
+				t.Errorf("Marshal got: %q err: %v want: %q", got, err, tt.Want)
+			}
+		})
+	}
+}
+
+func TestMarshalOverflowPolicy(t *testing.T) {
+	type overflowRecord struct {
+		Count  int     `flatfile:"1,4"`
+		Amount float64 `flatfile:"5,4"`
+	}
+
+	var tests = []struct {
+		V       overflowRecord
+		Policy  OverflowPolicy
+		Want    string
+		WantErr string
+	}{
+		{overflowRecord{123456, 12.345}, OverflowError, "", "Value 123456 is longer than the 4 bytes of the field"},
+		//the decimals of a float are dropped before its integer digits under every policy
+		{overflowRecord{123456, 12.345}, OverflowTruncateLeft, "345612.3", ""},
+		{overflowRecord{123456, 12.345}, OverflowTruncateRight, "123412.3", ""},
+		{overflowRecord{123456, 12.345}, OverflowSaturate, "999912.3", ""},
+		{overflowRecord{-123456, -123.45}, OverflowTruncateLeft, "-456-123", ""},
+		//a truncated decimal point is dropped
+		{overflowRecord{-123456, -123.45}, OverflowTruncateRight, "-123-123", ""},
+		{overflowRecord{-123456, -123.45}, OverflowSaturate, "-999-123", ""},
+		//a float whose integer digits do not fit loses all of its decimals first
+		{overflowRecord{0, 123456.7}, OverflowTruncateLeft, "   03456", ""},
+		{overflowRecord{0, 123456.7}, OverflowTruncateRight, "   01234", ""},
+		{overflowRecord{0, 123456.7}, OverflowSaturate, "   09999", ""},
+		{overflowRecord{0, 1234.5}, OverflowTruncateLeft, "   01234", ""},
+		//numbers that fit are written as usual
+		{overflowRecord{42, 1.5}, OverflowSaturate, "  42 1.5", ""},
+		{overflowRecord{123456, 0}, OverflowPolicy(9), "", "Unknown overflow policy 9"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestMarshalOverflowPolicy-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, err := Marshal(&tt.V, WithOverflowPolicy(tt.Policy))
+			if tt.WantErr == "" && (err != nil || string(got) != tt.Want) {
+				t.Errorf("Marshal(%+v) got: %q err: %v want: %q", tt.V, got, err, tt.Want)
+			}
+			if tt.WantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.WantErr)) {
+				t.Errorf("Marshal(%+v) err: %v want message containing: %s", tt.V, err, tt.WantErr)
+			}
+		})
+	}
+
+	//a sign alone cannot hold a number so it is always an error
+	if _, err := Marshal(&struct {
+		Count int `flatfile:"1,1"`
+	}{-5}, WithOverflowPolicy(OverflowSaturate)); err == nil {
+		t.Error("Marshal of a negative number into 1 byte should return an error")
+	}
+	if err := Unmarshal([]byte("00420015"), &overflowRecord{}, 0, 0, false, WithOverflowPolicy(OverflowSaturate)); err == nil || !strings.Contains(err.Error(), "WithOverflowPolicy only applies to Marshal") {
+		t.Errorf("Unmarshal err: %v want message containing: WithOverflowPolicy only applies to Marshal", err)
+	}
+}
+
+func TestMarshalRoundingMode(t *testing.T) {
+	type roundRecord struct {
+		Rate   float64 `flatfile:"1,5,decimals=2"`
+		Amount float32 `flatfile:"6,4,decimals=0"`
+		Raw    float64 `flatfile:"10,5"`
+	}
+
+	var tests = []struct {
+		V    roundRecord
+		Opts []Option
+		Want string
+	}{
+		{roundRecord{2.345, 2.5, 2.345}, nil, " 2.35   32.345"},
+		{roundRecord{2.345, 2.5, 2.345}, []Option{WithRoundingMode(RoundHalfUp)}, " 2.35   32.345"},
+		{roundRecord{2.345, 2.5, 2.345}, []Option{WithRoundingMode(RoundHalfEven)}, " 2.34   22.345"},
+		{roundRecord{2.349, 3.5, 2.5}, []Option{WithRoundingMode(RoundHalfEven)}, " 2.35   4  2.5"},
+		{roundRecord{2.349, 3.5, 2.5}, []Option{WithRoundingMode(RoundTruncate)}, " 2.34   3  2.5"},
+		{roundRecord{-2.345, -2.5, 0}, nil, "-2.35  -3    0"},
+		{roundRecord{1.5, 0, 0}, nil, " 1.50   0    0"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestMarshalRoundingMode-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, err := Marshal(&tt.V, tt.Opts...)
+			if err != nil || string(got) != tt.Want {
+				t.Errorf("Marshal(%+v) got: %q err: %v want: %q", tt.V, got, err, tt.Want)
+			}
+		})
+	}
+
+	if err := Unmarshal([]byte("02.35"), &roundRecord{}, 0, 0, false, WithRoundingMode(RoundTruncate)); err == nil || !strings.Contains(err.Error(), "WithRoundingMode only applies to Marshal") {
+		t.Errorf("Unmarshal err: %v want message containing: WithRoundingMode only applies to Marshal", err)
+	}
+}
+
+func TestMarshalPercent(t *testing.T) {
+	type rateRecord struct {
+		Rate     float64  `flatfile:"1,5,percent=3"`
+		Fraction float64  `flatfile:"6,5,percent=3,fraction"`
+		Whole    *float32 `flatfile:"11,3,percent=0"`
+	}
+	whole := float32(12)
+
+	var tests = []struct {
+		V    rateRecord
+		Want string
+	}{
+		{rateRecord{2.55, 0.0255, &whole}, "0255002550012"},
+		{rateRecord{25.5, 0.255, &whole}, "2550025500012"},
+		{rateRecord{-1.5, 0.1, nil}, "-150010000   "},
+		//more decimals than the field implies are rounded half up
+		{rateRecord{2.5555, 0.025555, &whole}, "0255602556012"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestMarshalPercent-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, err := Marshal(&tt.V)
+			if err != nil || string(got) != tt.Want {
+				t.Fatalf("Marshal(%+v) got: %q err: %v want: %q", tt.V, got, err, tt.Want)
+			}
+		})
+	}
+
+	want := rateRecord{2.55, 0.0255, &whole}
+	data, err := Marshal(&want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := rateRecord{}
+	if err := Unmarshal(data, &got, 0, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal(Marshal) got: %+v want: %+v", got, want)
+	}
+}
+
+func TestTimeLayoutWidth(t *testing.T) {
+	var tests = []struct {
+		V       interface{}
+		WantMsg string
+	}{
+		{&struct {
+			Opened time.Time `flatfile:"1,10,layout=20060102"`
+		}{}, "Time layout 20060102 is 8 bytes wide but the field is 10 bytes"},
+		{&struct {
+			Opened []time.Time `flatfile:"1,6,2,layout=20060102"`
+		}{}, "Time layout 20060102 is 8 bytes wide but the field is 6 bytes"},
+		{&struct {
+			Opened sql.NullTime `flatfile:"1,8,layout=2006-01-02"`
+		}{}, "Time layout 2006-01-02 is 10 bytes wide but the field is 8 bytes"},
+		//the width of a layout with month names depends on the month so it is not checked
+		{&struct {
+			Opened time.Time `flatfile:"1,9,layout=January"`
+		}{}, ""},
+		{&struct {
+			Opened time.Time `flatfile:"1,9,layout=2006-1-2"`
+		}{}, ""},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestTimeLayoutWidth-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			err := ValidateSchema(tt.V)
+			if tt.WantMsg == "" && err != nil {
+				t.Errorf("ValidateSchema(%T) unexpected error %v", tt.V, err)
+			}
+			if tt.WantMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.WantMsg)) {
+				t.Errorf("ValidateSchema(%T) err: %v want message containing: %s", tt.V, err, tt.WantMsg)
+			}
+			if _, err := Marshal(tt.V); tt.WantMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.WantMsg)) {
+				t.Errorf("Marshal(%T) err: %v want message containing: %s", tt.V, err, tt.WantMsg)
+			}
+		})
+	}
+}
+
+func TestMarshalStrictOccurs(t *testing.T) {
+	type occursRecord struct {
+		Codes  []string `flatfile:"1,2,3"`
+		Scores [2]int   `flatfile:"7,2"`
+	}
+
+	var tests = []struct {
+		V       occursRecord
+		Opts    []Option
+		Want    string
+		WantErr string
+	}{
+		//a short slice leaves its remaining elements blank by default
+		{occursRecord{[]string{"AB"}, [2]int{1, 2}}, nil, "AB     1 2", ""},
+		{occursRecord{nil, [2]int{}}, nil, "       0 0", ""},
+		{occursRecord{[]string{"AB"}, [2]int{1, 2}}, []Option{WithStrictOccurs()}, "", "Slice of 1 elements is shorter than its occurs of 3"},
+		{occursRecord{nil, [2]int{}}, []Option{WithStrictOccurs()}, "", "Slice of 0 elements is shorter than its occurs of 3"},
+		//a full slice and arrays are unaffected
+		{occursRecord{[]string{"AB", "CD", "EF"}, [2]int{1, 2}}, []Option{WithStrictOccurs()}, "ABCDEF 1 2", ""},
+		{occursRecord{[]string{"AB", "CD", "EF", "GH"}, [2]int{}}, nil, "", "Slice of 4 elements is longer than its occurs of 3"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestMarshalStrictOccurs-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, err := Marshal(&tt.V, tt.Opts...)
+			if tt.WantErr == "" && (err != nil || string(got) != tt.Want) {
+				t.Errorf("Marshal(%+v) got: %q err: %v want: %q", tt.V, got, err, tt.Want)
+			}
+			if tt.WantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.WantErr)) {
+				t.Errorf("Marshal(%+v) err: %v want message containing: %s", tt.V, err, tt.WantErr)
+			}
+		})
+	}
+
+	if err := Unmarshal([]byte("ABCDEF 1 2"), &occursRecord{}, 0, 0, false, WithStrictOccurs()); err == nil || !strings.Contains(err.Error(), "WithStrictOccurs only applies to Marshal") {
+		t.Errorf("Unmarshal err: %v want message containing: WithStrictOccurs only applies to Marshal", err)
+	}
+}
+
+func TestMarshalOverlap(t *testing.T) {
+	//Whole is written first, then Code as it is declared later at the same column, then Count and Suffix by column
+	type overlapRecord struct {
+		Whole  string `flatfile:"1,6"`
+		Code   string `flatfile:"1,2"`
+		Suffix string `flatfile:"5,2"`
+		Count  int    `flatfile:"3,2"`
+	}
+
+	var tests = []struct {
+		V    overlapRecord
+		Want string
+	}{
+		{overlapRecord{"ABCDEF", "XY", "ZZ", 7}, "XY 7ZZ"},
+		//a shorter value leaves the bytes written before it while a number is padded across its whole field
+		{overlapRecord{"ABCDEF", "X", "Z", 7}, "XB 7ZF"},
+		{overlapRecord{"ABCDEF", "", "", 0}, "AB 0EF"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestMarshalOverlap-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, err := Marshal(&tt.V)
+			if err != nil || string(got) != tt.Want {
+				t.Errorf("Marshal(%+v) got: %q err: %v want: %q", tt.V, got, err, tt.Want)
+			}
+		})
+	}
+}
+
+func TestMarshalErr(t *testing.T) {
+	var tests = []struct {
+		V       interface{}
+		WantMsg string
+	}{
+		{"AMY", "Expected a struct or pointer to a struct but got string"},
+		{&struct {
+			Name string `flatfile:"1,3"`
+		}{"AMELIA"}, "field Name col 1 len 3: flatfile.putLeft: Value \"AMELIA\" is longer than the 3 bytes of the field"},
+		{&struct {
+			Count int `flatfile:"1,3"`
+		}{-123}, "Value -123 is longer than the 3 bytes of the field"},
+		{&struct {
+			Codes []string `flatfile:"1,2,2"`
+		}{[]string{"A", "B", "C"}}, "Slice of 3 elements is longer than its occurs of 2"},
+		{&struct {
+			Codes []string `flatfile:"1,2,-1"`
+		}{}, "has a variable length"},
+		{&struct {
+			Amount float64 `flatfile:"1,8,,money"`
+		}{}, "Fields using the money option cannot be marshalled"},
+		{&struct {
+			Inner struct {
+				Name string `flatfile:"1,5"`
+			} `flatfile:"1,3"`
+		}{}, "Field Name ends at col 5 after the 3 bytes of the record"},
+		{&struct {
+			Scores [2]int `flatfile:"1,1"`
+		}{[2]int{1, 10}}, "Failed to marshal element 1 at col 2"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestMarshalErr-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			_, err := Marshal(tt.V)
+			if err == nil || !strings.Contains(err.Error(), tt.WantMsg) {
+				t.Errorf("Marshal(%+v) err: %v want message containing: %s", tt.V, err, tt.WantMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package flatfile
+
+import (
+	"bytes"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+//Option configures optional behaviour of Unmarshal
+type Option func(*unmarshalOptions)
+
+//unmarshalOptions holds the behaviour selected by the Options passed to Unmarshal
+type unmarshalOptions struct {
+	//hasOptions is set when any Option is passed. Options disable the all string fast path
+	hasOptions bool
+	zeroFirst  bool
+	encoding   *Encoding
+	//encodingField names the field whose value selects the encoding of the record from encodings
+	encodingField string
+	encodings     map[string]*Encoding
+	//partialLastField allows the last tagged field to take fewer than len bytes when the record ends early
+	partialLastField bool
+	//limit is the number of bytes of data considered, 0 for all of it
+	limit int
+	//separator is removed from data before any field is parsed, "" for none
+	separator string
+	//maxRecords is the most records unmarshalled into a slice of structs, 0 for no limit
+	maxRecords int
+	//strictKinds makes a tagged field of a kind that cannot be assigned an error instead of leaving it untouched
+	strictKinds bool
+	//strictNumeric checks numeric fields hold only digits before they are parsed
+	strictNumeric bool
+	//strictLength makes data longer than the record length of the struct an error
+	strictLength bool
+	//lenient assigns the zero value to a bool or numeric field that fails to parse, collecting the error in warnings
+	lenient  bool
+	warnings *[]error
+	//profile is the name of the tag profile selected by WithProfile, "" for the default
+	profile string
+	//overrides replaces the tags of the named fields of the top level struct for WithTagOverrides
+	overrides map[string]string
+	//overrideLayouts caches the layout of each struct type with overrides applied
+	overrideLayouts map[reflect.Type]*structLayout
+	//trueText and falseText are the text of a bool field whose tag maps neither value, "" for T and F
+	trueText  string
+	falseText string
+	//normalize is applied to the text of string fields after decoding and before trimming, nil for none
+	normalize func(string) string
+	//readOnly is set when data is a view of a string, so user code is given a copy of field data it may modify
+	readOnly bool
+	//zeroTimeFill is written across a zero time.Time field by Marshal, 0 to leave it blank
+	zeroTimeFill byte
+	//zeroPad makes Marshal pad numbers with zeros after their sign rather than spaces
+	zeroPad bool
+	//strictOccurs makes Marshal reject a slice with fewer elements than its occurs
+	strictOccurs bool
+	//overflow is what Marshal does with a number longer than its field
+	overflow OverflowPolicy
+	//rounding is how Marshal rounds a float field to its decimals option
+	rounding RoundingMode
+	//raw collects the bytes of each field of the top level struct by field name for UnmarshalWithRaw
+	raw map[string][]byte
+}
+
+func newUnmarshalOptions(opts []Option) *unmarshalOptions {
+	o := &unmarshalOptions{hasOptions: len(opts) > 0}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+//WithZeroFirst zeroes each tagged field before it is parsed so a struct reused across records does not retain stale data
+//Pointer fields that are already allocated have the value they point to zeroed instead of being set to nil
+func WithZeroFirst() Option {
+	return func(o *unmarshalOptions) {
+		o.zeroFirst = true
+	}
+}
+
+//zeroField sets field to the zero value of its type
+//Callback fields are left as they are not data
+func zeroField(field reflect.Value) {
+	if field.Type() == fieldCallbackType {
+		return
+	}
+	if field.Kind() == reflect.Ptr && !field.IsNil() {
+		field.Elem().Set(reflect.Zero(field.Elem().Type()))
+		return
+	}
+	field.Set(reflect.Zero(field.Type()))
+}
+
+//WithPartialLastField lets the last tagged field of a struct take whatever bytes remain when the record ends before the field does
+//This suits trailing free text fields declared with a generous length
+func WithPartialLastField() Option {
+	return func(o *unmarshalOptions) {
+		o.partialLastField = true
+	}
+}
+
+//WithLimit only considers the first limit bytes of data, ignoring trailing bytes such as the framing of a larger message
+//Fields beyond the limit are treated as if the record ended there. They are skipped if they start after it
+//and return an error if they straddle it, unless WithPartialLastField is also given
+func WithLimit(limit int) Option {
+	return func(o *unmarshalOptions) {
+		o.limit = limit
+	}
+}
+
+//WithSeparator removes every occurrence of sep from the record before its fields are parsed, then parses the remaining bytes by column
+//It suits files that are fixed width apart from a separator such as | added between fields for readability e.g. AMY|0100|CA
+//Columns are those of the record without separators. A field whose value contains sep loses it too, so sep must not occur in the data
+//The separators are removed from a copy of data, so the raw bytes of UnmarshalWithRaw are slices of the copy
+func WithSeparator(sep string) Option {
+	return func(o *unmarshalOptions) {
+		o.separator = sep
+	}
+}
+
+//WithMaxRecords stops unmarshalling into a slice of structs after maxRecords records
+//Data after them, such as padding following a known number of records, is ignored
+func WithMaxRecords(maxRecords int) Option {
+	return func(o *unmarshalOptions) {
+		o.maxRecords = maxRecords
+	}
+}
+
+//WithStrictKinds returns an error naming the field for a tagged field of a kind Unmarshal cannot assign e.g. chan, func or map
+//Without it such fields are silently left untouched. Use it for production schemas so a struct never quietly drops data
+func WithStrictKinds() Option {
+	return func(o *unmarshalOptions) {
+		o.strictKinds = true
+	}
+}
+
+//WithStrictNumeric trims whitespace from integer and float fields and rejects any that hold more than digits after an optional leading sign
+//Floats may also have one decimal point. The error quotes the data e.g. Non-numeric data "12O4" in place of the strconv error,
+//telling corrupt data apart from a value out of range. Fields using the money flag or a byte or rune override are not checked
+func WithStrictNumeric() Option {
+	return func(o *unmarshalOptions) {
+		o.strictNumeric = true
+	}
+}
+
+//WithStrictLength returns an error when data is longer than the record length of the struct, the end of its furthest field
+//Trailing bytes are otherwise ignored. Together with the error for a record that ends before a field does this enforces an exact length
+//The struct must not have variable length fields. A partial unmarshal is not checked
+//When unmarshalling into a slice it rejects data left after WithMaxRecords records
+func WithStrictLength() Option {
+	return func(o *unmarshalOptions) {
+		o.strictLength = true
+	}
+}
+
+//checkLength returns an error if WithStrictLength is set and data is longer than a record of structType
+func (o *unmarshalOptions) checkLength(data []byte, structType reflect.Type) error {
+	if !o.strictLength {
+		return nil
+	}
+	layout, err := o.structLayout(structType)
+	if err != nil {
+		return err
+	}
+	recLength, err := layout.recordLength(structType)
+	if err != nil {
+		return errors.Wrap(err, "flatfile.WithStrictLength: Cannot check record length")
+	}
+	if len(data) > recLength {
+		return errors.Errorf("flatfile.WithStrictLength: Record of length %d is longer than the %d bytes of %s", len(data), recLength, structType)
+	}
+	return nil
+}
+
+//WithLenientValues assigns the zero value to a bool or numeric field that fails to parse instead of returning an error
+//The *FieldError of each such field is appended to warnings, which may be nil, and the rest of the record is unmarshalled
+//It suits best effort loading of dirty data where bad values are triaged later. Other errors, such as a short record, are still returned
+func WithLenientValues(warnings *[]error) Option {
+	return func(o *unmarshalOptions) {
+		o.lenient = true
+		o.warnings = warnings
+	}
+}
+
+//warn appends err to the warnings of WithLenientValues and returns true if a failure to assign a field of type t is lenient
+func (o *unmarshalOptions) warn(t reflect.Type, err error) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if !o.lenient || (t.Kind() != reflect.Bool && !isNumericKind(t.Kind())) {
+		return false
+	}
+	if o.warnings != nil {
+		*o.warnings = append(*o.warnings, err)
+	}
+	return true
+}
+
+//WithProfile unmarshals with the named profile of tags that declare several layouts e.g. `flatfile:"1,10;v2=1,12"`
+//Tags without the profile use their default, so only the fields that differ between versions need one. A profile of "-" skips the field
+//It applies to nested structs too
+func WithProfile(profile string) Option {
+	return func(o *unmarshalOptions) {
+		o.profile = profile
+	}
+}
+
+//WithBoolText sets the text of bool fields whose tag has no true or false option and no boolmode=numeric, T and F by default
+//Marshal writes t or f and Unmarshal reads only t or f, e.g. WithBoolText("Y", "N") for a file of Y and N flags
+func WithBoolText(t, f string) Option {
+	return func(o *unmarshalOptions) {
+		o.trueText = t
+		o.falseText = f
+	}
+}
+
+//WithTagOverrides replaces the flatfile tags of the named struct fields for this call, leaving other fields with their struct tags
+//It lets a struct read a variant of its layout e.g. a legacy file where Amount moved: WithTagOverrides(map[string]string{"Amount": "21,9"})
+//An override of "-" skips the field. Overrides apply to the struct passed to Unmarshal, or each record of a slice, not to nested structs
+func WithTagOverrides(overrides map[string]string) Option {
+	return func(o *unmarshalOptions) {
+		o.overrides = overrides
+		o.overrideLayouts = make(map[reflect.Type]*structLayout)
+	}
+}
+
+//structLayout returns the layout of struct type t with any tag overrides applied
+func (o *unmarshalOptions) structLayout(t reflect.Type) (*structLayout, error) {
+	if o.overrides == nil {
+		return cachedProfileLayout(t, o.profile), nil
+	}
+	if layout, ok := o.overrideLayouts[t]; ok {
+		return layout, nil
+	}
+	for name := range o.overrides {
+		if structField, ok := t.FieldByName(name); !ok || len(structField.Index) != 1 {
+			return nil, errors.Errorf("flatfile.WithTagOverrides: %s has no field %s", t, name)
+		}
+	}
+	layout := newStructLayout(t, o.overrides, o.profile)
+	o.overrideLayouts[t] = layout
+	return layout, nil
+}
+
+//userData returns fieldData to hand to user code such as a callback, copied if data must not be modified
+func (o *unmarshalOptions) userData(fieldData []byte) []byte {
+	if o.readOnly {
+		return append([]byte{}, fieldData...)
+	}
+	return fieldData
+}
+
+//prepareData applies the pre-passes of the options to data before any field is parsed
+//The limit of WithLimit is taken from the data as given, then the separators of WithSeparator are removed
+func (o *unmarshalOptions) prepareData(data []byte) ([]byte, error) {
+	if option := o.unmarshalUnsupported(); option != "" {
+		return nil, errors.Errorf("flatfile.Unmarshal: %s only applies to Marshal", option)
+	}
+	data, err := o.limitData(data)
+	if err != nil || o.separator == "" {
+		return data, err
+	}
+	return bytes.Replace(data, []byte(o.separator), nil, -1), nil
+}
+
+//limitData returns the part of data within the limit set by WithLimit
+func (o *unmarshalOptions) limitData(data []byte) ([]byte, error) {
+	if o.limit < 0 {
+		return nil, errors.Errorf("flatfile.WithLimit: Out of range error. Limit %d cannot be less than 0", o.limit)
+	}
+	if o.limit > 0 && o.limit < len(data) {
+		return data[:o.limit], nil
+	}
+	return data, nil
+}
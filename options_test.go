@@ -0,0 +1,504 @@
+package flatfile
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithZeroFirst_Unmarshal(t *testing.T) {
+	type Profile struct {
+		Name    string `flatfile:"1,3"`
+		Age     int    `flatfile:"4,2"`
+		Country string `flatfile:"6,2"`
+	}
+
+	var tests = []struct {
+		Opts []Option
+		Want Profile
+	}{
+		{nil, Profile{Name: "BOB", Age: 20, Country: "CA"}},
+		{[]Option{WithZeroFirst()}, Profile{Name: "BOB", Age: 20}},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestWithZeroFirst_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := &Profile{}
+			if err := Unmarshal([]byte("AMY19CA"), got, 0, 0, false, tt.Opts...); err != nil {
+				t.Errorf("err: %s", err)
+			}
+			if err := Unmarshal([]byte("BOB20"), got, 0, 0, false, tt.Opts...); err != nil {
+				t.Errorf("err: %s", err)
+			}
+			if *got != tt.Want {
+				t.Errorf("Unmarshal reused struct got: %v want: %v", *got, tt.Want)
+			}
+		})
+	}
+}
+
+func TestWithZeroFirstPointer_Unmarshal(t *testing.T) {
+	type Profile struct {
+		Name string `flatfile:"1,3"`
+		Age  *int   `flatfile:"4,2"`
+	}
+
+	age := 19
+	got := &Profile{Name: "AMY", Age: &age}
+	if err := Unmarshal([]byte("BOB"), got, 0, 0, false, WithZeroFirst()); err != nil {
+		t.Errorf("err: %s", err)
+	}
+	if got.Name != "BOB" || got.Age == nil || *got.Age != 0 {
+		t.Errorf("Unmarshal(BOB) got: %v want: {BOB 0}", got)
+	}
+}
+
+func TestWithPartialLastField_Unmarshal(t *testing.T) {
+	type Profile struct {
+		Name    string `flatfile:"1,3"`
+		Comment string `flatfile:"4,20"`
+		Note    string
+	}
+
+	var tests = []struct {
+		Record []byte
+		Want   Profile
+	}{
+		{[]byte("AMYshort comment"), Profile{Name: "AMY", Comment: "short comment"}},
+		{[]byte("AMY"), Profile{Name: "AMY"}},
+		{[]byte("AMYa comment of twenty!!!"), Profile{Name: "AMY", Comment: "a comment of twenty!"}},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestWithPartialLastField_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := Profile{}
+			err := Unmarshal(tt.Record, &got, 0, 0, false, WithPartialLastField())
+			if err != nil {
+				t.Errorf("err: %s", err)
+			}
+			if got != tt.Want {
+				t.Errorf("Unmarshal(%s) got: %v want: %v", string(tt.Record), got, tt.Want)
+			}
+		})
+	}
+}
+
+func TestWithLimit_Unmarshal(t *testing.T) {
+	type Profile struct {
+		Name string `flatfile:"1,3"`
+		Age  int    `flatfile:"4,2"`
+		Code string `flatfile:"6,2"`
+	}
+
+	var tests = []struct {
+		Record  []byte
+		Opts    []Option
+		Want    Profile
+		WantErr bool
+	}{
+		{[]byte("AMY30CA|FRAME"), []Option{WithLimit(7)}, Profile{Name: "AMY", Age: 30, Code: "CA"}, false},
+		{[]byte("AMY30|FRAME"), []Option{WithLimit(5)}, Profile{Name: "AMY", Age: 30}, false},
+		{[]byte("AMY30C|FRAME"), []Option{WithLimit(6)}, Profile{Name: "AMY", Age: 30}, true},
+		{[]byte("AMY30C|FRAME"), []Option{WithLimit(6), WithPartialLastField()}, Profile{Name: "AMY", Age: 30, Code: "C"}, false},
+		{[]byte("AMY30CA"), []Option{WithLimit(100)}, Profile{Name: "AMY", Age: 30, Code: "CA"}, false},
+		{[]byte("AMY30CA"), []Option{WithLimit(-1)}, Profile{}, true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestWithLimit_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := Profile{}
+			err := Unmarshal(tt.Record, &got, 0, 0, false, tt.Opts...)
+			if (err != nil) != tt.WantErr {
+				t.Fatalf("Unmarshal(%s) err: %v want err: %v", string(tt.Record), err, tt.WantErr)
+			}
+			if got != tt.Want {
+				t.Errorf("Unmarshal(%s) got: %v want: %v", string(tt.Record), got, tt.Want)
+			}
+		})
+	}
+}
+
+func TestWithSeparator_Unmarshal(t *testing.T) {
+	type Profile struct {
+		Name string `flatfile:"1,3"`
+		Age  int    `flatfile:"4,2"`
+		Code string `flatfile:"6,2"`
+	}
+
+	var tests = []struct {
+		Record  []byte
+		Opts    []Option
+		Want    Profile
+		WantErr bool
+	}{
+		{[]byte("AMY|30|CA"), []Option{WithSeparator("|")}, Profile{Name: "AMY", Age: 30, Code: "CA"}, false},
+		{[]byte("|AMY|30|CA|"), []Option{WithSeparator("|")}, Profile{Name: "AMY", Age: 30, Code: "CA"}, false},
+		{[]byte("AMY ; 30 ; CA"), []Option{WithSeparator(" ; ")}, Profile{Name: "AMY", Age: 30, Code: "CA"}, false},
+		{[]byte("AMY|30|CA|FRAME"), []Option{WithSeparator("|"), WithLimit(9)}, Profile{Name: "AMY", Age: 30, Code: "CA"}, false},
+		{[]byte("AMY|30|CA"), []Option{WithSeparator("")}, Profile{}, true},
+		{[]byte("AMY|30|CA|X"), []Option{WithSeparator("|"), WithStrictLength()}, Profile{}, true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestWithSeparator_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := Profile{}
+			err := Unmarshal(tt.Record, &got, 0, 0, false, tt.Opts...)
+			if (err != nil) != tt.WantErr {
+				t.Fatalf("Unmarshal(%s) err: %v want err: %v", string(tt.Record), err, tt.WantErr)
+			}
+			if !tt.WantErr && got != tt.Want {
+				t.Errorf("Unmarshal(%s) got: %v want: %v", string(tt.Record), got, tt.Want)
+			}
+		})
+	}
+
+	var records []Profile
+	data := []byte("AMY|30|CABOB|41|US")
+	if err := Unmarshal(data, &records, 0, 0, false, WithSeparator("|")); err != nil || len(records) != 2 || records[1] != (Profile{Name: "BOB", Age: 41, Code: "US"}) {
+		t.Errorf("Unmarshal(%s) records got: %v err: %v", data, records, err)
+	}
+	raw, err := UnmarshalWithRaw([]byte("AMY|30|CA"), &Profile{}, WithSeparator("|"))
+	if err != nil || string(raw["Code"]) != "CA" {
+		t.Errorf("UnmarshalWithRaw(AMY|30|CA) raw got: %q err: %v", raw, err)
+	}
+}
+
+func TestWithMaxRecords_Unmarshal(t *testing.T) {
+	type Item struct {
+		Code string `flatfile:"1,2"`
+		Qty  int    `flatfile:"3,2"`
+	}
+
+	var tests = []struct {
+		Data    string
+		Opts    []Option
+		Want    []Item
+		WantErr bool
+	}{
+		{"AA01BB02CC03", nil, []Item{{"AA", 1}, {"BB", 2}, {"CC", 3}}, false},
+		{"AA01BB02    ", []Option{WithMaxRecords(2)}, []Item{{"AA", 1}, {"BB", 2}}, false},
+		{"AA01BB02CC03", []Option{WithMaxRecords(5)}, []Item{{"AA", 1}, {"BB", 2}, {"CC", 3}}, false},
+		{"AA01BB0", nil, nil, true},
+		{"AA01    ", nil, nil, true},
+		{"", nil, []Item{}, false},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestWithMaxRecords_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			var got []Item
+			err := Unmarshal([]byte(tt.Data), &got, 0, 0, false, tt.Opts...)
+			if (err != nil) != tt.WantErr {
+				t.Fatalf("Unmarshal(%s) err: %v want err: %v", tt.Data, err, tt.WantErr)
+			}
+			if !tt.WantErr && !reflect.DeepEqual(got, tt.Want) {
+				t.Errorf("Unmarshal(%s) got: %v want: %v", tt.Data, got, tt.Want)
+			}
+		})
+	}
+
+	var ptrs []*Item
+	if err := Unmarshal([]byte("AA01BB02"), &ptrs, 0, 0, false); err != nil || len(ptrs) != 2 || *ptrs[1] != (Item{"BB", 2}) {
+		t.Errorf("Unmarshal into a slice of struct pointers got: %v err: %v", ptrs, err)
+	}
+	if err := Unmarshal([]byte("AA01"), &ptrs, 1, 0, true); err == nil {
+		t.Error("Unmarshal should return an error for a partial unmarshal into a slice")
+	}
+}
+
+func TestWithStrictKinds_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Name  string           `flatfile:"1,5"`
+		Codes map[string]int   `flatfile:"6,2"`
+		Hook  func()           `flatfile:"8,1"`
+		Ch    chan int         `flatfile:"9,1"`
+		Ptr   *map[string]bool `flatfile:"10,1"`
+	}
+
+	var tests = []struct {
+		Opts      []Option
+		WantField string
+	}{
+		{nil, ""},
+		{[]Option{WithStrictKinds()}, "Codes"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestWithStrictKinds_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := &FfpTest{}
+			err := Unmarshal([]byte("HelloAB123"), got, 0, 0, false, tt.Opts...)
+			if tt.WantField == "" {
+				if err != nil || got.Name != "Hello" || got.Codes != nil {
+					t.Errorf("Unmarshal got: %+v err: %v want unsupported kinds left untouched", got, err)
+				}
+				return
+			}
+			fieldErr, ok := err.(*FieldError)
+			if !ok || fieldErr.Field != tt.WantField || !strings.Contains(err.Error(), "Unsupported kind map") {
+				t.Errorf("Unmarshal err: %v want unsupported kind error for field %s", err, tt.WantField)
+			}
+		})
+	}
+
+	ptr := &struct {
+		Ptr *func() `flatfile:"1,1"`
+	}{}
+	if err := Unmarshal([]byte("A"), ptr, 0, 0, false, WithStrictKinds()); err == nil || !strings.Contains(err.Error(), "Unsupported kind func") {
+		t.Errorf("Unmarshal err: %v want unsupported kind error for a pointer to func", err)
+	}
+}
+
+func TestWithStrictLength_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Code string `flatfile:"1,2"`
+		Qty  int    `flatfile:"3,2"`
+	}
+	type Variable struct {
+		Codes []string `flatfile:"1,2,-1"`
+	}
+
+	var tests = []struct {
+		Data    string
+		V       interface{}
+		Opts    []Option
+		WantErr string
+	}{
+		{"AA01XX", &FfpTest{}, nil, ""},
+		{"AA01", &FfpTest{}, []Option{WithStrictLength()}, ""},
+		{"AA01XX", &FfpTest{}, []Option{WithStrictLength()}, "Record of length 6 is longer than the 4 bytes"},
+		{"AA0", &FfpTest{}, []Option{WithStrictLength()}, "ends before the field ends"},
+		{"AA01XX", &FfpTest{}, []Option{WithStrictLength(), WithLimit(4)}, ""},
+		{"AABB", &Variable{}, []Option{WithStrictLength()}, "variable length"},
+		{"AA01BB02", &[]FfpTest{}, []Option{WithStrictLength()}, ""},
+		{"AA01BB02", &[]FfpTest{}, []Option{WithStrictLength(), WithMaxRecords(2)}, ""},
+		{"AA01BB02    ", &[]FfpTest{}, []Option{WithMaxRecords(2)}, ""},
+		{"AA01BB02    ", &[]FfpTest{}, []Option{WithStrictLength(), WithMaxRecords(2)}, "4 bytes remain after 2 records"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestWithStrictLength_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			err := Unmarshal([]byte(tt.Data), tt.V, 0, 0, false, tt.Opts...)
+			if tt.WantErr == "" && err != nil {
+				t.Errorf("Unmarshal(%s) unexpected error: %v", tt.Data, err)
+			}
+			if tt.WantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.WantErr)) {
+				t.Errorf("Unmarshal(%s) err: %v want message containing: %s", tt.Data, err, tt.WantErr)
+			}
+		})
+	}
+
+	if _, err := UnmarshalWithRaw([]byte("AA01XX"), &FfpTest{}, WithStrictLength()); err == nil {
+		t.Error("UnmarshalWithRaw should apply WithStrictLength")
+	}
+	if err := Unmarshal([]byte("01XX"), &FfpTest{}, 1, 0, true, WithStrictLength()); err != nil {
+		t.Errorf("WithStrictLength should not apply to a partial unmarshal got: %v", err)
+	}
+}
+
+func TestWithTagOverrides_Unmarshal(t *testing.T) {
+	type Inner struct {
+		Code string `flatfile:"1,2"`
+	}
+	type FfpTest struct {
+		Name   string `flatfile:"1,5"`
+		Amount int    `flatfile:"6,3"`
+		Note   string
+		Inner  Inner `flatfile:"9,2"`
+	}
+
+	var tests = []struct {
+		Data      string
+		Overrides map[string]string
+		Want      FfpTest
+		WantErr   string
+	}{
+		{"Hello123AB", nil, FfpTest{Name: "Hello", Amount: 123, Inner: Inner{"AB"}}, ""},
+		{"123HelloAB", map[string]string{"Name": "4,5", "Amount": "1,3"}, FfpTest{Name: "Hello", Amount: 123, Inner: Inner{"AB"}}, ""},
+		{"Hello123ABxy", map[string]string{"Note": "11,2"}, FfpTest{Name: "Hello", Amount: 123, Note: "xy", Inner: Inner{"AB"}}, ""},
+		{"HelloXYZAB", map[string]string{"Amount": "-"}, FfpTest{Name: "Hello", Inner: Inner{"AB"}}, ""},
+		{"Hello123AB", map[string]string{"Code": "1,1"}, FfpTest{}, "has no field Code"},
+		{"Hello123AB", map[string]string{"Amount": "6"}, FfpTest{}, "Field Amount has invalid tag 6"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestWithTagOverrides_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := &FfpTest{}
+			err := Unmarshal([]byte(tt.Data), got, 0, 0, false, WithTagOverrides(tt.Overrides))
+			if tt.WantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.WantErr) {
+					t.Errorf("Unmarshal(%s) err: %v want message containing: %s", tt.Data, err, tt.WantErr)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(*got, tt.Want) {
+				t.Errorf("Unmarshal(%s) got: %+v err: %v want: %+v", tt.Data, *got, err, tt.Want)
+			}
+		})
+	}
+
+	var records []FfpTest
+	err := Unmarshal([]byte("001HelloAB002WorldCD"), &records, 0, 0, false, WithTagOverrides(map[string]string{"Name": "4,5", "Amount": "1,3"}))
+	if err != nil || len(records) != 2 || records[1].Name != "World" || records[1].Amount != 2 {
+		t.Errorf("Unmarshal records with overrides got: %+v err: %v", records, err)
+	}
+	if got := (&FfpTest{}); Unmarshal([]byte("Hello123AB"), got, 0, 0, false) != nil || got.Amount != 123 {
+		t.Errorf("WithTagOverrides should not change the cached tags got: %+v", got)
+	}
+}
+
+func TestWithStrictNumeric_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Qty   int     `flatfile:"1,4"`
+		Count uint8   `flatfile:"5,3"`
+		Rate  float64 `flatfile:"8,6"`
+		Code  string  `flatfile:"14,2"`
+	}
+
+	var tests = []struct {
+		Data    string
+		Want    FfpTest
+		WantErr string
+	}{
+		{"  42 7  -1.25AB", FfpTest{42, 7, -1.25, "AB"}, ""},
+		{"+042007  2.5 AB", FfpTest{42, 7, 2.5, "AB"}, ""},
+		{"12O4007  1.25AB", FfpTest{}, "field Qty col 1 len 4 value \"12O4\": flatfile.assignBasedOnKind: AssignmentError: flatfile.checkNumeric: Non-numeric data \"12O4\""},
+		{"--42007  1.25AB", FfpTest{}, "Non-numeric data \"--42\""},
+		{"0042   1.25  AB", FfpTest{}, "Non-numeric data \"   \""},
+		{"0042007 1.2.5AB", FfpTest{}, "Non-numeric data \" 1.2.5\""},
+		{"0042300  1.25AB", FfpTest{}, "value out of range"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestWithStrictNumeric_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := FfpTest{}
+			err := Unmarshal([]byte(tt.Data), &got, 0, 0, false, WithStrictNumeric())
+			if tt.WantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.WantErr) {
+					t.Errorf("Unmarshal(%s) err: %v want message containing: %s", tt.Data, err, tt.WantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.Want {
+				t.Errorf("Unmarshal(%s) got: %+v err: %v want: %+v", tt.Data, got, err, tt.Want)
+			}
+		})
+	}
+}
+
+func TestWithLenientValues_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Qty    int      `flatfile:"1,4"`
+		Active bool     `flatfile:"5,1"`
+		Rate   *float64 `flatfile:"6,4"`
+		Name   string   `flatfile:"10,3"`
+	}
+
+	var tests = []struct {
+		Data         string
+		WantQty      int
+		WantActive   bool
+		WantRate     bool
+		WantWarnings []string
+	}{
+		{"0042T1.50BOB", 42, true, true, nil},
+		{"12O4T1.50BOB", 0, true, true, []string{"field Qty col 1 len 4 value \"12O4\""}},
+		{"0042Xabc BOB", 42, false, false, []string{"field Active col 5 len 1 value \"X\"", "field Rate col 6 len 4 value \"abc \""}},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestWithLenientValues_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := FfpTest{Qty: 9, Active: true}
+			var warnings []error
+			if err := Unmarshal([]byte(tt.Data), &got, 0, 0, false, WithLenientValues(&warnings)); err != nil {
+				t.Fatalf("Unmarshal(%s) unexpected err: %v", tt.Data, err)
+			}
+			if got.Qty != tt.WantQty || got.Active != tt.WantActive || (got.Rate != nil) != tt.WantRate || got.Name != "BOB" {
+				t.Errorf("Unmarshal(%s) got: %+v", tt.Data, got)
+			}
+			if len(warnings) != len(tt.WantWarnings) {
+				t.Fatalf("Unmarshal(%s) warnings got: %v want: %v", tt.Data, warnings, tt.WantWarnings)
+			}
+			for i, want := range tt.WantWarnings {
+				if _, ok := warnings[i].(*FieldError); !ok || !strings.Contains(warnings[i].Error(), want) {
+					t.Errorf("Unmarshal(%s) warning %d got: %v want a *FieldError containing: %s", tt.Data, i, warnings[i], want)
+				}
+			}
+			if tt.WantWarnings != nil && Unmarshal([]byte(tt.Data), &FfpTest{}, 0, 0, false) == nil {
+				t.Errorf("Unmarshal(%s) without WithLenientValues should return an error", tt.Data)
+			}
+		})
+	}
+
+	if err := Unmarshal([]byte("0042T1.5"), &FfpTest{}, 0, 0, false, WithLenientValues(nil)); err == nil {
+		t.Error("WithLenientValues should still return an error for a record that ends before a field")
+	}
+}
+
+func TestWithProfile_Unmarshal(t *testing.T) {
+	type Address struct {
+		City string `flatfile:"1,6;v2=1,8"`
+	}
+	type FfpTest struct {
+		Name    string  `flatfile:"1,10;v2=1,12"`
+		Amount  int     `flatfile:"11,3;v2=13,3;v3=-"`
+		Address Address `flatfile:"14,6;v2=16,8"`
+		Code    string  `flatfile:"v1=20,2;v2=24,2"`
+	}
+
+	var tests = []struct {
+		Data    string
+		Profile string
+		Want    FfpTest
+	}{
+		{"Alice     042TorontAB", "", FfpTest{"Alice     ", 42, Address{"Toront"}, "AB"}},
+		{"Alice     042TorontAB", "v1", FfpTest{"Alice     ", 42, Address{"Toront"}, "AB"}},
+		{"Alice       042Toronto CD", "v2", FfpTest{"Alice       ", 42, Address{"Toronto "}, "CD"}},
+		{"Alice     XXXTorontAB", "v3", FfpTest{"Alice     ", 0, Address{"Toront"}, "AB"}},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestWithProfile_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := FfpTest{}
+			err := Unmarshal([]byte(tt.Data), &got, 0, 0, false, WithProfile(tt.Profile))
+			if err != nil || got != tt.Want {
+				t.Errorf("Unmarshal(%s) profile %s got: %+v err: %v want: %+v", tt.Data, tt.Profile, got, err, tt.Want)
+			}
+		})
+	}
+
+	var tagTests = []struct {
+		Tag     string
+		Profile string
+		Want    string
+		WantErr bool
+	}{
+		{"1,10", "v2", "1,10", false},
+		{"col=1,len=10", "v2", "col=1,len=10", false},
+		{"1,10;v2=col=1,len=12", "v2", "col=1,len=12", false},
+		{"v1=1,10;v2=1,12", "", "1,10", false},
+		{"v1=1,10;1,8", "", "1,8", false},
+		{"1,10; v2 = 1,12", "v2", "1,12", false},
+		{"1,10;v2=1,12;v2=1,14", "v2", "", true},
+		{"1,10;1,12", "", "", true},
+	}
+	for idx, tt := range tagTests {
+		testName := fmt.Sprintf("TestWithProfile_profileTag-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, err := profileTag(tt.Tag, tt.Profile)
+			if (err != nil) != tt.WantErr || got != tt.Want {
+				t.Errorf("profileTag(%s,%s) got: %s err: %v want: %s", tt.Tag, tt.Profile, got, err, tt.Want)
+			}
+		})
+	}
+}
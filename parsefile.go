@@ -0,0 +1,52 @@
+package flatfile
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+//ParseFile unmarshals a buffer of concatenated records that may each have a different layout
+//The record type code is read from typeCol (1-indexed) for typeLen bytes at the start of each record
+//dispatch returns a pointer to a new struct to unmarshal a record of that type into, or nil if the type is unknown
+//The length of each record is determined from the tags of the struct returned by dispatch
+func ParseFile(data []byte, dispatch func(recordType string) interface{}, typeCol, typeLen int) ([]interface{}, error) {
+	if typeCol < 1 || typeLen < 1 {
+		return nil, errors.Errorf("flatfile.ParseFile: Out of range error. Type column %d and length %d cannot be less than 1", typeCol, typeLen)
+	}
+	var records []interface{}
+	for offset := 0; offset < len(data); {
+		remaining := data[offset:]
+		if len(remaining) < typeCol-1+typeLen {
+			return records, errors.Errorf("flatfile.ParseFile: Record at offset %d is too short to contain a record type", offset)
+		}
+		recordType := string(remaining[typeCol-1 : typeCol-1+typeLen])
+
+		record := dispatch(recordType)
+		if record == nil {
+			return records, errors.Errorf("flatfile.ParseFile: Unknown record type %q at offset %d", recordType, offset)
+		}
+		recordValue := reflect.ValueOf(record)
+		if recordValue.Kind() != reflect.Ptr || recordValue.Elem().Kind() != reflect.Struct {
+			return records, errors.Errorf("flatfile.ParseFile: Record type %q dispatched %T which is not a pointer to a struct", recordType, record)
+		}
+
+		recLength, err := recordLength(recordValue.Elem().Type())
+		if err != nil {
+			return records, errors.Wrapf(err, "flatfile.ParseFile: Record type %q", recordType)
+		}
+		if recLength == 0 {
+			return records, errors.Errorf("flatfile.ParseFile: Record type %q dispatched %T which has no flatfile tags", recordType, record)
+		}
+		if len(remaining) < recLength {
+			return records, errors.Errorf("flatfile.ParseFile: Record type %q at offset %d is %d bytes but only %d bytes remain", recordType, offset, recLength, len(remaining))
+		}
+
+		if err := Unmarshal(remaining[:recLength], record, 0, 0, false); err != nil {
+			return records, errors.Wrapf(err, "flatfile.ParseFile: Failed to unmarshal record type %q at offset %d", recordType, offset)
+		}
+		records = append(records, record)
+		offset += recLength
+	}
+	return records, nil
+}
@@ -0,0 +1,79 @@
+package flatfile
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testHeader struct {
+	Type string `flatfile:"1,1"`
+	Date string `flatfile:"2,8"`
+}
+
+type testDetail struct {
+	Type   string `flatfile:"1,1"`
+	Name   string `flatfile:"2,3"`
+	Amount int    `flatfile:"5,4"`
+}
+
+type testTrailer struct {
+	Type  string `flatfile:"1,1"`
+	Count int    `flatfile:"2,2"`
+}
+
+func testDispatch(recordType string) interface{} {
+	switch recordType {
+	case "H":
+		return &testHeader{}
+	case "D":
+		return &testDetail{}
+	case "T":
+		return &testTrailer{}
+	}
+	return nil
+}
+
+func TestParseFile(t *testing.T) {
+	data := []byte("H20200101DAMY0100DBOB0250T02")
+
+	records, err := ParseFile(data, testDispatch, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []interface{}{
+		&testHeader{Type: "H", Date: "20200101"},
+		&testDetail{Type: "D", Name: "AMY", Amount: 100},
+		&testDetail{Type: "D", Name: "BOB", Amount: 250},
+		&testTrailer{Type: "T", Count: 2},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("ParseFile(%s) got: %v want: %v", data, records, want)
+	}
+}
+
+func TestParseFileErr(t *testing.T) {
+	var tests = []struct {
+		Data    string
+		WantMsg string
+	}{
+		{"H20200101XAMY0100", "Unknown record type \"X\" at offset 9"},
+		{"H20200101DAMY01", "is 8 bytes but only 6 bytes remain"},
+		{"H20200101DAMYXXXX", "Failed to unmarshal record type \"D\" at offset 9"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestParseFileErr-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			records, err := ParseFile([]byte(tt.Data), testDispatch, 1, 1)
+			if err == nil || !strings.Contains(err.Error(), tt.WantMsg) {
+				t.Errorf("ParseFile(%s) err: %v want message containing: %s", tt.Data, err, tt.WantMsg)
+			}
+			if len(records) != 1 {
+				t.Errorf("ParseFile(%s) should return the records parsed before the error got: %v", tt.Data, records)
+			}
+		})
+	}
+}
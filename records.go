@@ -0,0 +1,51 @@
+//go:build go1.23
+// +build go1.23
+
+package flatfile
+
+import (
+	"io"
+	"iter"
+
+	"github.com/pkg/errors"
+)
+
+//Records returns an iterator over the fixed length records read from r for use with range
+//Each record is recordLen bytes and is unmarshalled into a new value returned by proto, which must return a pointer
+//An error unmarshalling a record is yielded with that record and iteration continues with the next one
+//Iteration stops cleanly at EOF. A read error or a short last record is yielded once and ends iteration
+func Records(r io.Reader, recordLen int, proto func() interface{}) iter.Seq2[interface{}, error] {
+	return func(yield func(interface{}, error) bool) {
+		if recordLen < 1 {
+			yield(nil, errors.Errorf("flatfile.Records: Out of range error. Record length %d cannot be less than 1", recordLen))
+			return
+		}
+		buf := make([]byte, recordLen)
+		for recordNum := 1; ; recordNum++ {
+			n, err := io.ReadFull(r, buf)
+			if err == io.EOF {
+				return
+			}
+			if err == io.ErrUnexpectedEOF {
+				yield(nil, errors.Errorf("flatfile.Records: Record %d is %d bytes but only %d bytes remain", recordNum, recordLen, n))
+				return
+			}
+			if err != nil {
+				yield(nil, errors.Wrapf(err, "flatfile.Records: Failed to read record %d", recordNum))
+				return
+			}
+
+			record := proto()
+			if err := Unmarshal(buf, record, 0, 0, false); err != nil {
+				err = errors.Wrapf(err, "flatfile.Records: Failed to unmarshal record %d", recordNum)
+				if !yield(record, err) {
+					return
+				}
+				continue
+			}
+			if !yield(record, nil) {
+				return
+			}
+		}
+	}
+}
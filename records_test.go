@@ -0,0 +1,65 @@
+//go:build go1.23
+// +build go1.23
+
+package flatfile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecords(t *testing.T) {
+	data := "DAMY0100DBOBXXXXDCAT0300"
+	proto := func() interface{} { return &testDetail{} }
+
+	var records []interface{}
+	var errs []error
+	for rec, err := range Records(strings.NewReader(data), 8, proto) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		records = append(records, rec)
+	}
+
+	want := []interface{}{
+		&testDetail{Type: "D", Name: "AMY", Amount: 100},
+		&testDetail{Type: "D", Name: "CAT", Amount: 300},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("Records(%s) got: %v want: %v", data, records, want)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "Failed to unmarshal record 2") {
+		t.Errorf("Records(%s) errors got: %v want one error for record 2", data, errs)
+	}
+}
+
+func TestRecordsShortRecord(t *testing.T) {
+	data := "DAMY0100DBOB"
+	proto := func() interface{} { return &testDetail{} }
+
+	count := 0
+	var lastErr error
+	for _, err := range Records(strings.NewReader(data), 8, proto) {
+		count++
+		lastErr = err
+	}
+	if count != 2 || lastErr == nil || !strings.Contains(lastErr.Error(), "Record 2 is 8 bytes but only 4 bytes remain") {
+		t.Errorf("Records(%s) got %d records with last error: %v", data, count, lastErr)
+	}
+}
+
+func TestRecordsBreak(t *testing.T) {
+	data := "DAMY0100DBOB0250"
+	proto := func() interface{} { return &testDetail{} }
+
+	count := 0
+	for range Records(strings.NewReader(data), 8, proto) {
+		count++
+		break
+	}
+	if count != 1 {
+		t.Errorf("Records(%s) should stop when the loop breaks got %d iterations", data, count)
+	}
+}
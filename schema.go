@@ -0,0 +1,450 @@
+package flatfile
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+//Schema is a record layout defined outside of Go struct tags, such as one loaded by LoadSchema
+type Schema struct {
+	Fields []SchemaField
+}
+
+//SchemaField is the layout of a single field of a Schema
+type SchemaField struct {
+	Name string `json:"name"`
+	//Col is the 1-indexed column the field starts at
+	Col    int `json:"col"`
+	Length int `json:"len"`
+	//Occurs repeats the field, unmarshalling it as a slice. 0 is a single value and -1 repeats to the end of the record
+	Occurs int `json:"occurs,omitempty"`
+	//Type is the name of the Go type the field is unmarshalled as e.g. string, int, float64 or bool. Defaults to string
+	Type string `json:"type,omitempty"`
+
+	tag     flatfileTag
+	rawTag  string
+	rawType reflect.Type
+}
+
+//schemaTypes maps the type names of a schema field to their Go type
+var schemaTypes = map[string]reflect.Type{
+	"string":  reflect.TypeOf(""),
+	"bool":    reflect.TypeOf(false),
+	"int":     reflect.TypeOf(int(0)),
+	"int8":    reflect.TypeOf(int8(0)),
+	"int16":   reflect.TypeOf(int16(0)),
+	"int32":   reflect.TypeOf(int32(0)),
+	"int64":   reflect.TypeOf(int64(0)),
+	"uint":    reflect.TypeOf(uint(0)),
+	"uint8":   reflect.TypeOf(uint8(0)),
+	"uint16":  reflect.TypeOf(uint16(0)),
+	"uint32":  reflect.TypeOf(uint32(0)),
+	"uint64":  reflect.TypeOf(uint64(0)),
+	"float32": reflect.TypeOf(float32(0)),
+	"float64": reflect.TypeOf(float64(0)),
+}
+
+//NewSchema returns a Schema of fields after checking each field has a unique name, a valid layout and a known type
+func NewSchema(fields []SchemaField) (*Schema, error) {
+	schema := &Schema{Fields: make([]SchemaField, len(fields))}
+	names := make(map[string]bool, len(fields))
+	for i, field := range fields {
+		field.Name = strings.TrimSpace(field.Name)
+		if field.Name == "" {
+			return nil, errors.Errorf("flatfile.NewSchema: Field %d has no name", i+1)
+		}
+		if names[field.Name] {
+			return nil, errors.Errorf("flatfile.NewSchema: Field %s is defined more than once", field.Name)
+		}
+		names[field.Name] = true
+
+		if field.Type == "" {
+			field.Type = "string"
+		}
+		rawType, ok := schemaTypes[field.Type]
+		if !ok {
+			return nil, errors.Errorf("flatfile.NewSchema: Field %s has unknown type %s", field.Name, field.Type)
+		}
+		field.rawType = rawType
+
+		fieldTag := strconv.Itoa(field.Col) + "," + strconv.Itoa(field.Length)
+		if field.Occurs != 0 {
+			fieldTag += "," + strconv.Itoa(field.Occurs)
+		}
+		if err := parseFlatfileTag(fieldTag, &field.tag); err != nil {
+			return nil, errors.Wrapf(err, "flatfile.NewSchema: Field %s has invalid layout %s", field.Name, fieldTag)
+		}
+		field.rawTag = fieldTag
+		schema.Fields[i] = field
+	}
+	return schema, nil
+}
+
+//StructType builds a struct type with a tagged field for each schema field using reflect.StructOf
+//A pointer to a new value of the type e.g. reflect.New(t).Interface() can be passed to Unmarshal or returned by a ParseFile dispatch
+//Field names must be exported Go identifiers
+func (s *Schema) StructType() (reflect.Type, error) {
+	structFields := make([]reflect.StructField, len(s.Fields))
+	for i := range s.Fields {
+		field := &s.Fields[i]
+		if !isExportedIdentifier(field.Name) {
+			return nil, errors.Errorf("flatfile.Schema.StructType: Field %s is not an exported Go identifier", field.Name)
+		}
+		structFields[i] = reflect.StructField{
+			Name: field.Name,
+			Type: field.valueType(),
+			Tag:  reflect.StructTag(`flatfile:"` + field.rawTag + `"`),
+		}
+	}
+	return reflect.StructOf(structFields), nil
+}
+
+//isExportedIdentifier returns true if name can be the name of an exported struct field
+func isExportedIdentifier(name string) bool {
+	for i, r := range name {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return name != "" && unicode.IsUpper([]rune(name)[0])
+}
+
+//LoadSchema reads a Schema from a JSON or CSV layout spec
+//JSON is an array of fields e.g. [{"name":"Name","col":1,"len":10,"type":"string"}]
+//CSV has a header row naming the columns name, col, len and optionally occurs and type in any order e.g.
+//name,col,len,type
+//Name,1,10,string
+//Age,11,3,int
+func LoadSchema(r io.Reader) (*Schema, error) {
+	return LoadSchemaWithParams(r, nil)
+}
+
+//schemaParamRef matches a parameter reference in a layout spec e.g. ${NameLen}
+var schemaParamRef = regexp.MustCompile(`\$\{(\w+)\}`)
+
+//LoadSchemaWithParams reads a Schema like LoadSchema after replacing each parameter reference ${Name} in the spec with params["Name"]
+//This lets one spec serve several format variants e.g. {"name":"Name","col":1,"len":${NameLen}} or Name,1,${NameLen} in CSV
+//A reference to a parameter that is not in params is an error
+func LoadSchemaWithParams(r io.Reader, params map[string]string) (*Schema, error) {
+	spec, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "flatfile.LoadSchema: Failed to read schema")
+	}
+	var unresolved []string
+	spec = schemaParamRef.ReplaceAllFunc(spec, func(ref []byte) []byte {
+		name := string(schemaParamRef.FindSubmatch(ref)[1])
+		value, ok := params[name]
+		if !ok {
+			unresolved = append(unresolved, name)
+		}
+		return []byte(value)
+	})
+	if len(unresolved) > 0 {
+		return nil, errors.Errorf("flatfile.LoadSchema: Schema references parameters %v that are not defined", unresolved)
+	}
+	spec = bytes.TrimSpace(spec)
+	var fields []SchemaField
+	if bytes.HasPrefix(spec, []byte("[")) {
+		if err := json.Unmarshal(spec, &fields); err != nil {
+			return nil, errors.Wrap(err, "flatfile.LoadSchema: Failed to parse JSON schema")
+		}
+	} else {
+		fields, err = readSchemaCSV(bytes.NewReader(spec))
+		if err != nil {
+			return nil, err
+		}
+	}
+	schema, err := NewSchema(fields)
+	return schema, errors.Wrap(err, "flatfile.LoadSchema: Invalid schema")
+}
+
+//readSchemaCSV reads the fields of a CSV layout spec with a header row
+func readSchemaCSV(r io.Reader) ([]SchemaField, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "flatfile.LoadSchema: Failed to parse CSV schema")
+	}
+	if len(records) == 0 {
+		return nil, errors.New("flatfile.LoadSchema: CSV schema has no header row")
+	}
+
+	columns := make(map[string]int)
+	for idx, column := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(column))] = idx
+	}
+	for _, required := range []string{"name", "col", "len"} {
+		if _, ok := columns[required]; !ok {
+			return nil, errors.Errorf("flatfile.LoadSchema: CSV schema header %v is missing column %s", records[0], required)
+		}
+	}
+
+	fields := make([]SchemaField, 0, len(records)-1)
+	for line, record := range records[1:] {
+		value := func(column string) string {
+			if idx, ok := columns[column]; ok {
+				return strings.TrimSpace(record[idx])
+			}
+			return ""
+		}
+		field := SchemaField{Name: value("name"), Type: value("type")}
+		for column, dest := range map[string]*int{"col": &field.Col, "len": &field.Length, "occurs": &field.Occurs} {
+			if value(column) == "" {
+				continue
+			}
+			if *dest, err = strconv.Atoi(value(column)); err != nil {
+				return nil, errors.Wrapf(err, "flatfile.LoadSchema: Error parsing %s of CSV schema line %d", column, line+2)
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+//Unmarshal reads data into v using the layout of the schema instead of struct tags
+//v can be a map[string]interface{}, a pointer to one, or a pointer to a struct with a field named after each schema field
+//Map values are the Go type of each schema field, repeating fields are slices
+func (s *Schema) Unmarshal(data []byte, v interface{}) error {
+	vValue := reflect.ValueOf(v)
+	if vValue.Kind() == reflect.Ptr && !vValue.IsNil() && vValue.Elem().Kind() == reflect.Map {
+		if vValue.Elem().IsNil() && vValue.Elem().CanSet() && vValue.Elem().Type() == reflect.TypeOf(map[string]interface{}{}) {
+			vValue.Elem().Set(reflect.ValueOf(make(map[string]interface{})))
+		}
+		vValue = vValue.Elem()
+	}
+	if !vValue.IsValid() {
+		return errors.New("flatfile.Schema.Unmarshal: Cannot unmarshal into nil")
+	}
+	if m, ok := vValue.Interface().(map[string]interface{}); ok {
+		return s.unmarshalMap(data, m)
+	}
+	if vValue.Kind() == reflect.Ptr && !vValue.IsNil() && vValue.Elem().Kind() == reflect.Struct {
+		return s.unmarshalStruct(data, vValue.Elem())
+	}
+	return errors.Errorf("flatfile.Schema.Unmarshal: %T is not a map[string]interface{} or a pointer to a struct", v)
+}
+
+//fieldData returns the bytes of field in data when unmarshalled as type t, or nil if the record ends before the field starts
+//A greedy occurs takes every whole occurrence left in data, as in Unmarshal
+func (s *Schema) fieldData(data []byte, field *SchemaField, t reflect.Type) ([]byte, error) {
+	lowerBound := field.tag.col - 1
+	if lowerBound >= len(data) {
+		return nil, nil
+	}
+	if field.tag.occurs == greedyOccurs {
+		return data[lowerBound : lowerBound+(len(data)-lowerBound)/field.tag.length*field.tag.length], nil
+	}
+	upperBound := lowerBound + fieldWidth(t, &field.tag)
+	if upperBound > len(data) {
+		return nil, errors.Errorf("flatfile.Schema: Record of length %d ends before field %s col %d len %d", len(data), field.Name, field.tag.col, upperBound-lowerBound)
+	}
+	return data[lowerBound:upperBound], nil
+}
+
+//valueType returns the Go type a field is unmarshalled as
+func (field *SchemaField) valueType() reflect.Type {
+	if field.tag.occurs != 0 {
+		return reflect.SliceOf(field.rawType)
+	}
+	return field.rawType
+}
+
+func (s *Schema) unmarshalMap(data []byte, m map[string]interface{}) error {
+	if m == nil {
+		return errors.New("flatfile.Schema.Unmarshal: Cannot unmarshal into a nil map")
+	}
+	o := newUnmarshalOptions(nil)
+	for i := range s.Fields {
+		field := &s.Fields[i]
+		value := reflect.New(field.valueType()).Elem()
+		fieldData, err := s.fieldData(data, field, value.Type())
+		if err != nil {
+			return err
+		}
+		if fieldData == nil {
+			continue
+		}
+		if err := assignBasedOnKind(value.Kind(), value, fieldData, &field.tag, o); err != nil {
+			return &FieldError{Field: field.Name, Col: field.tag.col, Length: field.tag.length, Value: string(fieldData), Err: err}
+		}
+		m[field.Name] = value.Interface()
+	}
+	return nil
+}
+
+func (s *Schema) unmarshalStruct(data []byte, vStruct reflect.Value) error {
+	o := newUnmarshalOptions(nil)
+	for i := range s.Fields {
+		field := &s.Fields[i]
+		structField := vStruct.FieldByName(field.Name)
+		if !structField.IsValid() || !structField.CanSet() {
+			return errors.Errorf("flatfile.Schema.Unmarshal: %s has no exported field %s", vStruct.Type(), field.Name)
+		}
+		fieldData, err := s.fieldData(data, field, structField.Type())
+		if err != nil {
+			return err
+		}
+		if fieldData == nil {
+			continue
+		}
+		if err := assignBasedOnKind(structField.Kind(), structField, fieldData, &field.tag, o); err != nil {
+			return &FieldError{Field: field.Name, Col: field.tag.col, Length: field.tag.length, Value: string(fieldData), Err: err}
+		}
+	}
+	return nil
+}
+
+//FieldToken is the raw bytes of one field of a record sliced by Tokenize
+type FieldToken struct {
+	//Field is the layout of the field within the schema
+	Field SchemaField
+	//Data is the bytes of the field, a slice of the record rather than a copy
+	Data []byte
+}
+
+//Tokenize slices each field of schema from data without converting it to any type
+//Tokens are returned in schema order. Fields starting after the end of data are left out. A repeating field is one token of all of its occurrences
+func Tokenize(data []byte, schema *Schema) ([]FieldToken, error) {
+	tokens := make([]FieldToken, 0, len(schema.Fields))
+	for i := range schema.Fields {
+		field := &schema.Fields[i]
+		fieldData, err := schema.fieldData(data, field, field.valueType())
+		if err != nil {
+			return tokens, errors.Wrap(err, "flatfile.Tokenize")
+		}
+		if fieldData == nil {
+			continue
+		}
+		tokens = append(tokens, FieldToken{Field: *field, Data: fieldData})
+	}
+	return tokens, nil
+}
+
+//UnmarshalToMap slices each field of schema from data and stores it by field name with surrounding whitespace removed
+//Fields are not converted to their schema type, which suits exploring a file before its types are known
+//Fields starting after the end of data are left out of the map. A repeating field is stored as all of its occurrences
+func UnmarshalToMap(data []byte, schema *Schema) (map[string]string, error) {
+	tokens, err := Tokenize(data, schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "flatfile.UnmarshalToMap")
+	}
+	m := make(map[string]string, len(tokens))
+	for _, token := range tokens {
+		m[token.Field.Name] = strings.TrimSpace(string(token.Data))
+	}
+	return m, nil
+}
+
+//MarshalFromMap writes values as a fixed-width record using the layout of schema, the counterpart of UnmarshalToMap
+//Each schema field is written from the value of the same name like Marshal writes a struct field of its type
+//A field missing from values or nil is left blank, and values that are not schema fields are ignored
+//Values are converted to the schema type of their field. Numbers convert when no digits are lost, such as the float64 of a
+//decoded JSON number to an int field, and a string for a field that is not a string is parsed as Unmarshal would read it
+func MarshalFromMap(values map[string]interface{}, schema *Schema) ([]byte, error) {
+	recLength := 0
+	for i := range schema.Fields {
+		field := &schema.Fields[i]
+		if end := field.tag.col - 1 + fieldWidth(field.valueType(), &field.tag); end > recLength {
+			recLength = end
+		}
+	}
+	record := bytes.Repeat([]byte(" "), recLength)
+	o := newUnmarshalOptions(nil)
+	for i := range schema.Fields {
+		field := &schema.Fields[i]
+		value, ok := values[field.Name]
+		if !ok || value == nil {
+			continue
+		}
+		fieldValue, err := field.marshalValue(value, o)
+		if err != nil {
+			return nil, errors.Wrapf(err, "flatfile.MarshalFromMap: Field %s", field.Name)
+		}
+		lowerBound := field.tag.col - 1
+		fieldData := record[lowerBound : lowerBound+fieldWidth(field.valueType(), &field.tag)]
+		if err := marshalField(fieldData, fieldValue, &field.tag, o); err != nil {
+			return nil, errors.Wrapf(err, "flatfile.MarshalFromMap: Failed to marshal field %s col %d len %d", field.Name, field.tag.col, field.tag.length)
+		}
+	}
+	return record, nil
+}
+
+//marshalValue converts value to the Go type the field is unmarshalled as
+func (field *SchemaField) marshalValue(value interface{}, o *unmarshalOptions) (reflect.Value, error) {
+	t := field.valueType()
+	if text, ok := value.(string); ok && t.Kind() != reflect.String {
+		parsed := reflect.New(t).Elem()
+		if err := assignBasedOnKind(t.Kind(), parsed, []byte(text), &field.tag, o); err != nil {
+			return parsed, errors.Wrapf(err, "Failed to parse %q", text)
+		}
+		return parsed, nil
+	}
+	if t.Kind() != reflect.Slice {
+		return convertSchemaValue(reflect.ValueOf(value), t)
+	}
+	elems := reflect.ValueOf(value)
+	if elems.Kind() != reflect.Slice && elems.Kind() != reflect.Array {
+		return elems, errors.Errorf("Repeating field needs a slice not %T", value)
+	}
+	converted := reflect.MakeSlice(t, elems.Len(), elems.Len())
+	for i := 0; i < elems.Len(); i++ {
+		elem, err := convertSchemaValue(elems.Index(i), t.Elem())
+		if err != nil {
+			return elems, errors.Wrapf(err, "Element %d", i)
+		}
+		converted.Index(i).Set(elem)
+	}
+	return converted, nil
+}
+
+//convertSchemaValue converts v to type t. Strings and bools are not converted to or from other kinds
+//A number converted to an integer type must not lose its fraction or overflow
+func convertSchemaValue(v reflect.Value, t reflect.Type) (reflect.Value, error) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return v, errors.Errorf("Cannot write nil as %s", t)
+	}
+	switch {
+	case v.Kind() == reflect.String && t.Kind() == reflect.String, v.Kind() == reflect.Bool && t.Kind() == reflect.Bool:
+		return v.Convert(t), nil
+	case isNumericKind(v.Kind()) && isNumericKind(t.Kind()):
+		converted := v.Convert(t)
+		switch t.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return converted, nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if isNegative(v) {
+				return v, errors.Errorf("%v cannot be written as %s", v.Interface(), t)
+			}
+		}
+		if converted.Convert(v.Type()).Interface() != v.Interface() {
+			return v, errors.Errorf("%v cannot be written as %s without losing digits", v.Interface(), t)
+		}
+		return converted, nil
+	}
+	return v, errors.Errorf("%s cannot be written as %s", v.Type(), t)
+}
+
+//isNegative returns true if v is a number less than zero
+func isNegative(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() < 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() < 0
+	}
+	return false
+}
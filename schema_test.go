@@ -0,0 +1,350 @@
+package flatfile
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testCSVSchema = `name,col,len,type,occurs
+Name,1,5,string,
+Age,6,3,int,
+Scores,9,2,int,3
+Active,15,1,bool,
+`
+
+const testJSONSchema = `[
+	{"name":"Name","col":1,"len":5},
+	{"name":"Age","col":6,"len":3,"type":"int"},
+	{"name":"Scores","col":9,"len":2,"type":"int","occurs":3},
+	{"name":"Active","col":15,"len":1,"type":"bool"}
+]`
+
+func TestLoadSchema(t *testing.T) {
+	for _, spec := range []string{testCSVSchema, testJSONSchema} {
+		schema, err := LoadSchema(strings.NewReader(spec))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data := []byte("AMY  030102030T")
+		got := map[string]interface{}{}
+		if err := schema.Unmarshal(data, got); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]interface{}{"Name": "AMY  ", "Age": 30, "Scores": []int{10, 20, 30}, "Active": true}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Schema.Unmarshal(%s) map got: %v want: %v", data, got, want)
+		}
+
+		var gotPtr map[string]interface{}
+		if err := schema.Unmarshal(data, &gotPtr); err != nil || !reflect.DeepEqual(gotPtr, want) {
+			t.Errorf("Schema.Unmarshal(%s) map pointer got: %v err: %v want: %v", data, gotPtr, err, want)
+		}
+
+		type Person struct {
+			Name   string
+			Age    uint8
+			Scores [3]int
+			Active bool
+		}
+		gotStruct := &Person{}
+		if err := schema.Unmarshal(data, gotStruct); err != nil {
+			t.Fatal(err)
+		}
+		wantStruct := Person{Name: "AMY  ", Age: 30, Scores: [3]int{10, 20, 30}, Active: true}
+		if *gotStruct != wantStruct {
+			t.Errorf("Schema.Unmarshal(%s) struct got: %v want: %v", data, *gotStruct, wantStruct)
+		}
+	}
+}
+
+func TestSchemaStructType(t *testing.T) {
+	schema, err := LoadSchema(strings.NewReader(testCSVSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	structType, err := schema.StructType()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if structType.Name() != "" {
+		t.Errorf("Schema.StructType should build an anonymous struct got: %s", structType.Name())
+	}
+
+	data := []byte("AMY  030102030T")
+	record := reflect.New(structType)
+	if err := Unmarshal(data, record.Interface(), 0, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"Name": "AMY  ", "Age": 30, "Scores": []int{10, 20, 30}, "Active": true}
+	for name, wantValue := range want {
+		if got := record.Elem().FieldByName(name).Interface(); !reflect.DeepEqual(got, wantValue) {
+			t.Errorf("Unmarshal(%s) into StructType field %s got: %v want: %v", data, name, got, wantValue)
+		}
+	}
+
+	//a dispatch can return runtime built structs
+	records, err := ParseFile(append(data, data...), func(string) interface{} { return reflect.New(structType).Interface() }, 1, 1)
+	if err != nil || len(records) != 2 {
+		t.Errorf("ParseFile with a StructType dispatch got: %v err: %v", records, err)
+	}
+
+	badSchema, err := NewSchema([]SchemaField{{Name: "first name", Col: 1, Length: 5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := badSchema.StructType(); err == nil {
+		t.Error("Schema.StructType should return an error for a field name that is not a Go identifier")
+	}
+}
+
+func TestLoadSchemaWithParams(t *testing.T) {
+	csvSpec := "name,col,len\nName,1,${NameLen}\nCode,${CodeCol},2\n"
+	jsonSpec := `[{"name":"Name","col":1,"len":${NameLen}},{"name":"Code","col":${CodeCol},"len":2}]`
+	var tests = []struct {
+		Params map[string]string
+		Data   string
+		Want   map[string]string
+	}{
+		{map[string]string{"NameLen": "3", "CodeCol": "4"}, "AMYCA", map[string]string{"Name": "AMY", "Code": "CA"}},
+		{map[string]string{"NameLen": "5", "CodeCol": "6"}, "AMY  CA", map[string]string{"Name": "AMY", "Code": "CA"}},
+	}
+
+	for idx, tt := range tests {
+		for _, spec := range []string{csvSpec, jsonSpec} {
+			testName := fmt.Sprintf("TestLoadSchemaWithParams-%d", idx)
+			t.Run(testName, func(t *testing.T) {
+				schema, err := LoadSchemaWithParams(strings.NewReader(spec), tt.Params)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := UnmarshalToMap([]byte(tt.Data), schema)
+				if err != nil || !reflect.DeepEqual(got, tt.Want) {
+					t.Errorf("LoadSchemaWithParams(%s,%v) read %s got: %v err: %v want: %v", spec, tt.Params, tt.Data, got, err, tt.Want)
+				}
+			})
+		}
+	}
+}
+
+func TestLoadSchemaErr(t *testing.T) {
+	var tests = []struct {
+		Spec    string
+		WantMsg string
+	}{
+		{"name,col\nName,1", "missing column len"},
+		{"name,col,len\nName,1,x", "Error parsing len of CSV schema line 2"},
+		{"name,col,len\nName,0,5", "Field Name has invalid layout 0,5"},
+		{"name,col,len\nName,1,5\nName,6,5", "Field Name is defined more than once"},
+		{"name,col,len,type\nName,1,5,complex", "Field Name has unknown type complex"},
+		{"name,col,len\n,1,5", "Field 1 has no name"},
+		{`[{"name":"Name","col":"1"}]`, "Failed to parse JSON schema"},
+		{"", "CSV schema has no header row"},
+		{"name,col,len\nName,1,${NameLen}", "Schema references parameters [NameLen] that are not defined"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestLoadSchemaErr-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			_, err := LoadSchema(strings.NewReader(tt.Spec))
+			if err == nil || !strings.Contains(err.Error(), tt.WantMsg) {
+				t.Errorf("LoadSchema(%s) err: %v want message containing: %s", tt.Spec, err, tt.WantMsg)
+			}
+		})
+	}
+}
+
+func TestSchemaUnmarshalErr(t *testing.T) {
+	schema, err := LoadSchema(strings.NewReader(testCSVSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		Data    string
+		V       interface{}
+		WantMsg string
+	}{
+		{"AMY  XXX", map[string]interface{}{}, "Failed to unmarshal field Age"},
+		{"AMY  030102", map[string]interface{}{}, "ends before field Scores"},
+		{"AMY  030102030T", &struct{ Name string }{}, "has no exported field Age"},
+		{"AMY  030102030T", map[string]string{}, "is not a map[string]interface{} or a pointer to a struct"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestSchemaUnmarshalErr-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			err := schema.Unmarshal([]byte(tt.Data), tt.V)
+			if err == nil || !strings.Contains(err.Error(), tt.WantMsg) {
+				t.Errorf("Schema.Unmarshal(%s) err: %v want message containing: %s", tt.Data, err, tt.WantMsg)
+			}
+		})
+	}
+}
+
+func TestUnmarshalToMap(t *testing.T) {
+	schema, err := LoadSchema(strings.NewReader(testCSVSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		Data    string
+		Want    map[string]string
+		WantErr bool
+	}{
+		{"AMY  030102030T", map[string]string{"Name": "AMY", "Age": "030", "Scores": "102030", "Active": "T"}, false},
+		{" AMY XXX102030 ", map[string]string{"Name": "AMY", "Age": "XXX", "Scores": "102030", "Active": ""}, false},
+		{"AMY  030", map[string]string{"Name": "AMY", "Age": "030"}, false},
+		{"AMY  0301", nil, true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestUnmarshalToMap-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, err := UnmarshalToMap([]byte(tt.Data), schema)
+			if (err != nil) != tt.WantErr {
+				t.Fatalf("UnmarshalToMap(%s) err: %v want err: %v", tt.Data, err, tt.WantErr)
+			}
+			if !reflect.DeepEqual(got, tt.Want) {
+				t.Errorf("UnmarshalToMap(%s) got: %v want: %v", tt.Data, got, tt.Want)
+			}
+		})
+	}
+}
+
+func TestMarshalFromMap(t *testing.T) {
+	schema, err := LoadSchema(strings.NewReader(testCSVSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		Values  map[string]interface{}
+		Want    string
+		WantErr string
+	}{
+		{map[string]interface{}{"Name": "AMY", "Age": 30, "Scores": []int{10, 20, 30}, "Active": true}, "AMY   30102030T", ""},
+		//numbers decoded from JSON are float64 and repeating fields []interface{}
+		{map[string]interface{}{"Age": 30.0, "Scores": []interface{}{10.0, int64(20)}}, "      301020   ", ""},
+		//the strings of UnmarshalToMap are parsed as their schema type
+		{map[string]interface{}{"Name": "AMY", "Age": "030", "Scores": "102030", "Active": "T"}, "AMY   30102030T", ""},
+		{map[string]interface{}{"Age": nil, "Other": 1}, "               ", ""},
+		{map[string]interface{}{"Age": 30.5}, "", "Field Age: 30.5 cannot be written as int without losing digits"},
+		{map[string]interface{}{"Age": "X"}, "", "Field Age: Failed to parse \"X\""},
+		{map[string]interface{}{"Name": 5}, "", "Field Name: int cannot be written as string"},
+		{map[string]interface{}{"Scores": 5}, "", "Field Scores: Repeating field needs a slice not int"},
+		{map[string]interface{}{"Scores": []interface{}{1, "2"}}, "", "Field Scores: Element 1: string cannot be written as int"},
+		{map[string]interface{}{"Age": 1234}, "", "Failed to marshal field Age col 6 len 3"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestMarshalFromMap-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, err := MarshalFromMap(tt.Values, schema)
+			if tt.WantErr == "" && (err != nil || string(got) != tt.Want) {
+				t.Errorf("MarshalFromMap(%v) got: %q err: %v want: %q", tt.Values, got, err, tt.Want)
+			}
+			if tt.WantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.WantErr)) {
+				t.Errorf("MarshalFromMap(%v) err: %v want message containing: %s", tt.Values, err, tt.WantErr)
+			}
+		})
+	}
+
+	data := "AMY   30102030T"
+	m, err := UnmarshalToMap([]byte(data), schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string]interface{}, len(m))
+	for name, value := range m {
+		values[name] = value
+	}
+	if got, err := MarshalFromMap(values, schema); err != nil || string(got) != data {
+		t.Errorf("MarshalFromMap(UnmarshalToMap(%s)) got: %q err: %v", data, got, err)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	schema, err := LoadSchema(strings.NewReader(testJSONSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("AMY  030102030")
+	tokens, err := Tokenize(data, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		Name string
+		Col  int
+		Type string
+		Data string
+	}{
+		{"Name", 1, "string", "AMY  "},
+		{"Age", 6, "int", "030"},
+		{"Scores", 9, "int", "102030"},
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("Tokenize(%s) got %d tokens want: %d", data, len(tokens), len(want))
+	}
+	for idx, token := range tokens {
+		if token.Field.Name != want[idx].Name || token.Field.Col != want[idx].Col || token.Field.Type != want[idx].Type || string(token.Data) != want[idx].Data {
+			t.Errorf("Tokenize(%s) token %d got: %s %d %s %q want: %v", data, idx, token.Field.Name, token.Field.Col, token.Field.Type, token.Data, want[idx])
+		}
+	}
+}
+
+func TestSchemaGreedyOccurs(t *testing.T) {
+	schema, err := NewSchema([]SchemaField{
+		{Name: "ID", Col: 1, Length: 2},
+		{Name: "Codes", Col: 3, Length: 2, Occurs: -1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	//a greedy field takes every whole occurrence left in the record, as in struct Unmarshal
+	data := []byte("A1XXYYZZW")
+	want := []string{"XX", "YY", "ZZ"}
+
+	m := map[string]interface{}{}
+	if err := schema.Unmarshal(data, m); err != nil || !reflect.DeepEqual(m["Codes"], want) {
+		t.Errorf("Schema.Unmarshal(%s) got: %v err: %v want: %v", data, m["Codes"], err, want)
+	}
+	structType, err := schema.StructType()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromStruct := reflect.New(structType)
+	if err := Unmarshal(data, fromStruct.Interface(), 0, 0, false); err != nil || !reflect.DeepEqual(fromStruct.Elem().FieldByName("Codes").Interface(), want) {
+		t.Errorf("Unmarshal(%s) got: %v err: %v want: %v", data, fromStruct.Elem().FieldByName("Codes"), err, want)
+	}
+	tokens, err := Tokenize(data, schema)
+	if err != nil || len(tokens) != 2 || string(tokens[1].Data) != "XXYYZZ" {
+		t.Errorf("Tokenize(%s) got: %v err: %v want Codes token: XXYYZZ", data, tokens, err)
+	}
+}
+
+func TestGenerateStruct(t *testing.T) {
+	schema, err := LoadSchema(strings.NewReader(testCSVSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := GenerateStruct(schema, "Person")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "type Person struct {\n" +
+		"\tName   string `flatfile:\"1,5\"`\n" +
+		"\tAge    int    `flatfile:\"6,3\"`\n" +
+		"\tScores []int  `flatfile:\"9,2,3\"`\n" +
+		"\tActive bool   `flatfile:\"15,1\"`\n" +
+		"}\n"
+	if got != want {
+		t.Errorf("GenerateStruct got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := GenerateStruct(schema, "not a name"); err == nil {
+		t.Error("GenerateStruct should return an error for a type name that is not a Go identifier")
+	}
+}
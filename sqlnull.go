@@ -0,0 +1,70 @@
+package flatfile
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+var nullTimeType = reflect.TypeOf(sql.NullTime{})
+
+//sqlNullTypes are the database/sql null wrappers a field can be unmarshalled into
+//Each holds its value in the first field and whether it is set in the Valid field
+var sqlNullTypes = map[reflect.Type]bool{
+	reflect.TypeOf(sql.NullString{}):  true,
+	reflect.TypeOf(sql.NullInt64{}):   true,
+	reflect.TypeOf(sql.NullInt32{}):   true,
+	reflect.TypeOf(sql.NullFloat64{}): true,
+	reflect.TypeOf(sql.NullBool{}):    true,
+	nullTimeType:                      true,
+}
+
+//isSQLNullType returns true if t is one of the supported database/sql null types
+func isSQLNullType(t reflect.Type) bool {
+	return sqlNullTypes[t]
+}
+
+//isNestedStruct returns true if t is a struct whose own tagged fields are unmarshalled
+//Structs that unmarshal themselves, time.Time and the database/sql null types are assigned as a single value instead
+func isNestedStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !implementsFieldUnmarshaler(t) && t != timeType && !isSQLNullType(t)
+}
+
+//assignTime parses fieldData into a time.Time field using the layout option of the tag
+//A blank field is left as the zero time
+func assignTime(field reflect.Value, fieldData []byte, ffpTag *flatfileTag) error {
+	if ffpTag.layout == "" {
+		return errors.New("flatfile.assignTime: Time fields require a layout option e.g. `layout=20060102`")
+	}
+	value := strings.TrimSpace(string(fieldData))
+	if value == "" {
+		field.Set(reflect.Zero(timeType))
+		return nil
+	}
+	t, err := time.Parse(ffpTag.layout, value)
+	if err != nil {
+		return errors.Wrapf(err, "flatfile.assignTime: Failed to parse %q with layout %s", value, ffpTag.layout)
+	}
+	field.Set(reflect.ValueOf(t))
+	return nil
+}
+
+//assignSQLNull assigns fieldData to the value of a database/sql null type and sets Valid
+//A blank field, or one holding the null sentinel of the tag, is not valid
+func assignSQLNull(field reflect.Value, fieldData []byte, ffpTag *flatfileTag, o *unmarshalOptions) error {
+	field.Set(reflect.Zero(field.Type()))
+	value := strings.TrimSpace(string(fieldData))
+	if value == "" || (ffpTag.nullChk && value == ffpTag.nullVal) {
+		return nil
+	}
+	inner := field.Field(0)
+	if err := assignBasedOnKind(inner.Kind(), inner, fieldData, ffpTag, o); err != nil {
+		return errors.Wrapf(err, "flatfile.assignSQLNull: Failed to assign %s", field.Type())
+	}
+	field.FieldByName("Valid").SetBool(true)
+	return nil
+}
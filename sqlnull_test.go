@@ -0,0 +1,141 @@
+package flatfile
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sqlNullRecord struct {
+	Name    sql.NullString  `flatfile:"1,5"`
+	Count   sql.NullInt64   `flatfile:"6,3,null=999"`
+	Rate    sql.NullFloat64 `flatfile:"9,4"`
+	Active  sql.NullBool    `flatfile:"13,1,true=Y,false=N"`
+	Opened  sql.NullTime    `flatfile:"14,8,layout=20060102"`
+	Created time.Time       `flatfile:"22,8,layout=20060102"`
+}
+
+func TestSQLNull_Unmarshal(t *testing.T) {
+	date := time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC)
+	var tests = []struct {
+		Data string
+		Want sqlNullRecord
+	}{
+		{
+			"ALICE0421.25Y2020013120200131",
+			sqlNullRecord{
+				Name:    sql.NullString{String: "ALICE", Valid: true},
+				Count:   sql.NullInt64{Int64: 42, Valid: true},
+				Rate:    sql.NullFloat64{Float64: 1.25, Valid: true},
+				Active:  sql.NullBool{Bool: true, Valid: true},
+				Opened:  sql.NullTime{Time: date, Valid: true},
+				Created: date,
+			},
+		},
+		{
+			"     999             20200131",
+			sqlNullRecord{Created: date},
+		},
+		{
+			"BOB  0000000N        ",
+			sqlNullRecord{
+				Name:   sql.NullString{String: "BOB  ", Valid: true},
+				Count:  sql.NullInt64{Int64: 0, Valid: true},
+				Rate:   sql.NullFloat64{Float64: 0, Valid: true},
+				Active: sql.NullBool{Bool: false, Valid: true},
+			},
+		},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestSQLNull_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			var got sqlNullRecord
+			if err := Unmarshal([]byte(tt.Data), &got, 0, 0, true); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.Want) {
+				t.Errorf("Unmarshal(%s) got: %+v want: %+v", tt.Data, got, tt.Want)
+			}
+		})
+	}
+}
+
+func TestSQLNullErr_Unmarshal(t *testing.T) {
+	var tests = []struct {
+		Data   string
+		Record interface{}
+	}{
+		{"ABC", &struct {
+			Count sql.NullInt64 `flatfile:"1,3"`
+		}{}},
+		{"20201340", &struct {
+			Opened sql.NullTime `flatfile:"1,8,layout=20060102"`
+		}{}},
+		{"20200131", &struct {
+			Opened time.Time `flatfile:"1,8"`
+		}{}},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestSQLNullErr_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			if err := Unmarshal([]byte(tt.Data), tt.Record, 0, 0, false); err == nil {
+				t.Errorf("Unmarshal(%s) into %T should return an error", tt.Data, tt.Record)
+			}
+		})
+	}
+}
+
+func TestTimeField_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Clock   string    `flatfile:"9,6,layout=150405"`
+		Created time.Time `flatfile:"1,8,layout=20060102,timeField=Clock"`
+	}
+
+	var tests = []struct {
+		Data    string
+		Want    time.Time
+		WantErr string
+	}{
+		{"20200131235958", time.Date(2020, 1, 31, 23, 59, 58, 0, time.UTC), ""},
+		{"20200131      ", time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC), ""},
+		{"        235958", time.Time{}, ""},
+		{"20200131246000", time.Time{}, "Failed to parse time \"246000\" of field Clock"},
+		{"20200131", time.Time{}, "Time field Clock"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestTimeField_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			var got FfpTest
+			err := Unmarshal([]byte(tt.Data), &got, 0, 0, false)
+			if tt.WantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.WantErr) {
+					t.Errorf("Unmarshal(%s) err: %v want message containing: %s", tt.Data, err, tt.WantErr)
+				}
+				return
+			}
+			if err != nil || !got.Created.Equal(tt.Want) {
+				t.Errorf("Unmarshal(%s) got: %v err: %v want: %v", tt.Data, got.Created, err, tt.Want)
+			}
+		})
+	}
+
+	missing := &struct {
+		Created time.Time `flatfile:"1,8,layout=20060102,timeField=Clock"`
+	}{}
+	if err := Unmarshal([]byte("20200131"), missing, 0, 0, false); err == nil || !strings.Contains(err.Error(), "has no field Clock") {
+		t.Errorf("Unmarshal err: %v want an error for a missing time field", err)
+	}
+	noLayout := &struct {
+		Created time.Time `flatfile:"1,8,layout=20060102,timeField=Clock"`
+		Clock   string    `flatfile:"9,4"`
+	}{}
+	if err := Unmarshal([]byte("202001311200"), noLayout, 0, 0, false); err == nil || !strings.Contains(err.Error(), "requires a layout option") {
+		t.Errorf("Unmarshal err: %v want an error for a time field without a layout", err)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build go1.20
+// +build go1.20
+
+package flatfile
+
+import "unsafe"
+
+//stringBytes returns a read only []byte view of s sharing its memory
+//The returned slice must never be modified
+func stringBytes(s string) []byte {
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
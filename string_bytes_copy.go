@@ -0,0 +1,9 @@
+//go:build !go1.20
+// +build !go1.20
+
+package flatfile
+
+//stringBytes returns the bytes of s. Toolchains before go1.20 have no safe way to view the memory of a string so s is copied
+func stringBytes(s string) []byte {
+	return []byte(s)
+}
@@ -0,0 +1,73 @@
+package flatfile
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+//Transform modifies a field after it has been assigned from its data e.g. to normalize case
+type Transform func(field reflect.Value)
+
+//transformRegistry maps a transform name used in the transform tag option to its Transform
+var transformRegistry = struct {
+	sync.RWMutex
+	transforms map[string]Transform
+}{transforms: map[string]Transform{
+	"upper":     stringTransform(strings.ToUpper),
+	"lower":     stringTransform(strings.ToLower),
+	"trimspace": stringTransform(strings.TrimSpace),
+}}
+
+//RegisterTransform names a Transform so fields can apply it with the transform tag option e.g. `flatfile:"1,20,,transform=upper"`
+//Several transforms can be applied in order by separating their names with | e.g. `transform=trimspace|upper`
+//upper, lower and trimspace are provided and apply to string fields, including the strings of slices, arrays and pointers
+//Transforms must be registered before a struct using them is first unmarshalled
+func RegisterTransform(name string, transform Transform) error {
+	if name == "" || strings.Contains(name, "|") || transform == nil {
+		return errors.New("flatfile.RegisterTransform: Transform name without | and func must be provided")
+	}
+	transformRegistry.Lock()
+	defer transformRegistry.Unlock()
+	transformRegistry.transforms[name] = transform
+	return nil
+}
+
+//lookupTransform returns the Transform registered as name
+func lookupTransform(name string) (Transform, bool) {
+	transformRegistry.RLock()
+	defer transformRegistry.RUnlock()
+	transform, ok := transformRegistry.transforms[name]
+	return transform, ok
+}
+
+//applyTransforms applies the transforms named in the tag to field in order
+func applyTransforms(field reflect.Value, names []string) {
+	for _, name := range names {
+		if transform, ok := lookupTransform(name); ok {
+			transform(field)
+		}
+	}
+}
+
+//stringTransform returns a Transform applying fn to every string within a field
+func stringTransform(fn func(string) string) Transform {
+	var transform Transform
+	transform = func(field reflect.Value) {
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(fn(field.String()))
+		case reflect.Ptr:
+			if !field.IsNil() {
+				transform(field.Elem())
+			}
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < field.Len(); i++ {
+				transform(field.Index(i))
+			}
+		}
+	}
+	return transform
+}
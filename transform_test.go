@@ -0,0 +1,45 @@
+package flatfile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func init() {
+	RegisterTransform("testInitials", func(field reflect.Value) {
+		if field.Kind() == reflect.String && len(field.String()) > 0 {
+			field.SetString(field.String()[:1] + ".")
+		}
+	})
+}
+
+func TestTransform_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Name     string   `flatfile:"1,6,transform=trimspace|upper"`
+		Initial  string   `flatfile:"1,6,transform=trimspace|testInitials"`
+		Country  string   `flatfile:"7,2,transform=lower"`
+		Codes    []string `flatfile:"9,2,2,transform=upper"`
+		Nickname *string  `flatfile:"13,4,transform=trimspace"`
+		Count    int      `flatfile:"17,1,transform=upper"`
+	}
+
+	testVal := &FfpTest{}
+	err := Unmarshal([]byte("  amy CAabcd bo 3"), testVal, 0, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bo := "bo"
+	want := FfpTest{Name: "AMY", Initial: "a.", Country: "ca", Codes: []string{"AB", "CD"}, Nickname: &bo, Count: 3}
+	if !reflect.DeepEqual(*testVal, want) {
+		t.Errorf("Unmarshal transforms got: %+v want: %+v", *testVal, want)
+	}
+}
+
+func TestRegisterTransformErr(t *testing.T) {
+	for _, name := range []string{"", "a|b"} {
+		if err := RegisterTransform(name, func(reflect.Value) {}); err == nil || !strings.Contains(err.Error(), "RegisterTransform") {
+			t.Errorf("RegisterTransform(%q) err: %v", name, err)
+		}
+	}
+}
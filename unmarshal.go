@@ -2,6 +2,9 @@ package flatfile
 
 import (
 	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -13,10 +16,19 @@ func min(a, b int) int {
 	return b
 }
 
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 /*Unmarshal will read data and convert it into a struct based on a schema/map defined by struct tags
 
 Struct tags are in the form `flatfile:"col,len"`. col and len should be integers > 0
 
+Each field is read from its own col. Struct fields can be declared in any order
+
 startFieldIdx: index can be passed to indicate which struct field to start the unmarshal. Zero indexed.
 
 numFieldsToUnmarshal: can be passed to indicate how many fields to unmarshal starting from startFieldIdx
@@ -25,55 +37,250 @@ isPartialUnmarshal: flag should be set to true if the data[0] is intended to be
 
 If startFieldIdx == 0 and umFieldsToMarshal == 0 then Unmarshal will attempt to unmarshal all fields with an ffp tag
 
+v can also be a pointer to a slice of structs or struct pointers. data is then split into consecutive records the length of the struct,
+which must not have variable length fields. WithMaxRecords(n) stops after n records, ignoring any data after them
+
+opts: optional behaviour e.g. WithZeroFirst()
+
 */
-func Unmarshal(data []byte, v interface{}, startFieldIdx int, numFieldsToUnmarshal int, isPartialUnmarshal bool) error {
+func Unmarshal(data []byte, v interface{}, startFieldIdx int, numFieldsToUnmarshal int, isPartialUnmarshal bool, opts ...Option) error {
+	return unmarshalData(data, v, startFieldIdx, numFieldsToUnmarshal, isPartialUnmarshal, newUnmarshalOptions(opts))
+}
+
+//unmarshalData is Unmarshal with the options already built so entry points such as UnmarshalString can set internal ones
+func unmarshalData(data []byte, v interface{}, startFieldIdx int, numFieldsToUnmarshal int, isPartialUnmarshal bool, o *unmarshalOptions) error {
+	data, err := o.prepareData(data)
+	if err != nil {
+		return err
+	}
+	if vValue := reflect.ValueOf(v); vValue.Kind() == reflect.Ptr && !vValue.IsNil() && vValue.Elem().Kind() == reflect.Slice {
+		if startFieldIdx != 0 || numFieldsToUnmarshal != 0 || isPartialUnmarshal {
+			return errors.New("flatfile.Unmarshal: A partial unmarshal cannot be used with a slice of records")
+		}
+		return unmarshalRecords(data, vValue.Elem(), o)
+	}
+	if startFieldIdx == 0 && numFieldsToUnmarshal == 0 {
+		if err := checkStructLength(data, v, o); err != nil {
+			return err
+		}
+	}
+	return unmarshal(data, v, startFieldIdx, numFieldsToUnmarshal, isPartialUnmarshal, o)
+}
+
+//checkStructLength applies WithStrictLength to a full unmarshal of data into v when v is a pointer to a struct
+func checkStructLength(data []byte, v interface{}, o *unmarshalOptions) error {
+	vType := reflect.TypeOf(v)
+	if !o.strictLength || vType == nil || vType.Kind() != reflect.Ptr || vType.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return o.checkLength(data, vType.Elem())
+}
+
+//unmarshalRecords splits data into consecutive records of the element struct of sliceValue and sets the slice to them
+func unmarshalRecords(data []byte, sliceValue reflect.Value, o *unmarshalOptions) error {
+	elemType := sliceValue.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return errors.Errorf("flatfile.Unmarshal: Unmarshal not complete. %s is not a slice of structs", sliceValue.Type())
+	}
+	layout, err := o.structLayout(structType)
+	if err != nil {
+		return err
+	}
+	recLength, err := layout.recordLength(structType)
+	if err != nil {
+		return errors.Wrapf(err, "flatfile.Unmarshal: Cannot split data into records of %s", structType)
+	}
+	if recLength == 0 {
+		return errors.Errorf("flatfile.Unmarshal: %s has no flatfile tags", structType)
+	}
+
+	records := reflect.MakeSlice(sliceValue.Type(), 0, len(data)/recLength)
+	for offset := 0; offset < len(data); offset += recLength {
+		if o.maxRecords > 0 && records.Len() == o.maxRecords {
+			if o.strictLength {
+				return errors.Errorf("flatfile.WithStrictLength: %d bytes remain after %d records", len(data)-offset, o.maxRecords)
+			}
+			break
+		}
+		if len(data)-offset < recLength {
+			return errors.Errorf("flatfile.Unmarshal: Record %d is %d bytes but only %d bytes remain", records.Len()+1, recLength, len(data)-offset)
+		}
+		record := reflect.New(structType)
+		if err := unmarshal(data[offset:offset+recLength], record.Interface(), 0, 0, false, o); err != nil {
+			return errors.Wrapf(err, "flatfile.Unmarshal: Failed to unmarshal record %d", records.Len()+1)
+		}
+		if elemType.Kind() == reflect.Ptr {
+			records = reflect.Append(records, record)
+		} else {
+			records = reflect.Append(records, record.Elem())
+		}
+	}
+	sliceValue.Set(records)
+	return nil
+}
+
+//UnmarshalWithRaw unmarshals data into v like Unmarshal and also returns the raw bytes of each field keyed by struct field name
+//The raw bytes are slices of data, not copies. Fields of a nested struct are part of the raw bytes of the struct field
+//Fields that were reached before an error are returned with the error
+func UnmarshalWithRaw(data []byte, v interface{}, opts ...Option) (map[string][]byte, error) {
+	o := newUnmarshalOptions(opts)
+	o.hasOptions = true
+	o.raw = make(map[string][]byte)
+	data, err := o.prepareData(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStructLength(data, v, o); err != nil {
+		return o.raw, err
+	}
+	err = unmarshal(data, v, 0, 0, false, o)
+	return o.raw, err
+}
+
+//unmarshal is the implementation of Unmarshal with the options already applied so they can be passed to nested structs
+func unmarshal(data []byte, v interface{}, startFieldIdx int, numFieldsToUnmarshal int, isPartialUnmarshal bool, o *unmarshalOptions) error {
 	colOffset := 0
-	//init ffpTag for later use
-	ffpTag := &flatfileTag{}
-	if reflect.TypeOf(v).Kind() == reflect.Ptr {
+	if reflect.TypeOf(v) != nil && reflect.TypeOf(v).Kind() == reflect.Ptr {
+		if reflect.ValueOf(v).IsNil() {
+			return errors.Errorf("flatfile.Unmarshal: Unmarshal not complete. %s is nil", reflect.TypeOf(v))
+		}
 		//Get underlying type
 		vType := reflect.TypeOf(v).Elem()
 
 		//Only process if kind is Struct
 		if vType.Kind() == reflect.Struct {
+			if startFieldIdx < 0 || startFieldIdx > vType.NumField() {
+				return errors.Errorf("flatfile.Unmarshal: Out of range error. startFieldIdx %d is not a field index of %s", startFieldIdx, vType)
+			}
+			layout, err := o.structLayout(vType)
+			if err != nil {
+				return err
+			}
+			//raw bytes are collected and tags overridden for the top level struct only, nested structs are covered by their own field
+			raw, overrides := o.raw, o.overrides
+			if raw != nil || overrides != nil {
+				o.raw, o.overrides = nil, nil
+				defer func() { o.raw, o.overrides = raw, overrides }()
+			}
 			//Dereference pointer to struct
 			vStruct := reflect.ValueOf(v).Elem()
-			maxField := 0
-			if numFieldsToUnmarshal > 0 {
-				maxField = min(startFieldIdx+numFieldsToUnmarshal, vStruct.NumField())
-			} else {
-				maxField = vStruct.NumField()
+			if layout.allStrings && startFieldIdx == 0 && numFieldsToUnmarshal == 0 && !o.hasOptions {
+				return unmarshalAllStrings(data, vStruct, layout)
+			}
+			//in a partial unmarshal data starts at the first column needed by the fields from startFieldIdx on
+			if startFieldIdx > 0 && isPartialUnmarshal {
+				colOffset = layout.firstColFrom(startFieldIdx) - 1
+			}
+			//a record that selects its own encoding has its other fields decoded with it
+			fieldOpts, encodingIdx := o, -1
+			if o.encodingField != "" {
+				if fieldOpts, encodingIdx, err = o.selectEncoding(data, colOffset, vType, layout); err != nil {
+					return err
+				}
+			}
+			//conditions are checked against the record as text
+			condData := textRecord(data, fieldOpts.encoding)
+			//fields are parsed in column order. A partial window of fields is taken in declaration order
+			fieldOrder := layout.byCol
+			if startFieldIdx > 0 || numFieldsToUnmarshal > 0 {
+				maxField := vStruct.NumField()
+				if numFieldsToUnmarshal > 0 {
+					maxField = min(startFieldIdx+numFieldsToUnmarshal, vStruct.NumField())
+				}
+				fieldOrder = make([]int, 0, maxField-startFieldIdx)
+				for i := startFieldIdx; i < maxField; i++ {
+					fieldOrder = append(fieldOrder, i)
+				}
 			}
 			//Loop through struct fields/properties
-			for i := startFieldIdx; i < maxField; i++ {
-
-				//Get underlying type of field
-				fieldType := vStruct.Field(i).Type()
-				fieldTag, tagFlag := vType.Field(i).Tag.Lookup("flatfile")
-				if tagFlag {
-
-					tagParseErr := parseFlatfileTag(fieldTag, ffpTag)
-					if tagParseErr != nil {
-						return errors.Wrapf(tagParseErr, "flatfile.Unmarshal: Failed to parse field tag %s", fieldTag)
+			for _, i := range fieldOrder {
+				field := &layout.fields[i]
+				if field.tagged {
+					fieldType, fieldValue := field.fieldType, vStruct.Field(i)
+					ffpTag := &field.tag
+					if field.err != nil {
+						return errors.Wrapf(field.err, "flatfile.Unmarshal: Field %s has invalid tag %s", field.name, field.rawTag)
 					}
-					if ShouldUnmarshal(ffpTag, data) {
-						//determine pos offset based on start index in case start index not 0 (1)
-						if i == startFieldIdx && startFieldIdx > 0 && isPartialUnmarshal {
-							colOffset = ffpTag.col - 1
-						}
-
+					if o.zeroFirst {
+						zeroField(fieldValue)
+					}
+					if ShouldUnmarshal(ffpTag, condData) {
 						//determine if the current field is in range of the posOffset passed
 						if ffpTag.col > colOffset {
 							//extract byte slice from byte data
 							lowerBound := ffpTag.col - 1 - colOffset
-							upperBound := lowerBound + ffpTag.length
+							if ffpTag.occursCol > 0 && lowerBound < len(data) {
+								//the occurs count is read from the record before the width of the field can be known
+								occursTag, err := resolveOccursAt(data, colOffset, fieldType, ffpTag)
+								if err != nil {
+									return &FieldError{Field: field.name, Col: ffpTag.col, Length: ffpTag.length, Err: err}
+								}
+								if occursTag.occurs == 0 {
+									fieldValue.Set(reflect.MakeSlice(fieldType, 0, 0))
+									continue
+								}
+								ffpTag = occursTag
+							}
+							upperBound := lowerBound + fieldWidth(fieldType, ffpTag)
 							//and check that pos does not exceed length of bytes to prevent attempting to parse nulls
 							if lowerBound < len(data) {
-								fieldData := data[lowerBound:upperBound]
-								err := assignBasedOnKind(fieldType.Kind(), vStruct.Field(i), fieldData, ffpTag)
+								var fieldData []byte
+								if ffpTag.occurs == greedyOccurs || ffpTag.lenPrefix {
+									fieldData = data[lowerBound:]
+								} else if o.partialLastField && i == layout.lastTagged && upperBound > len(data) {
+									//the last field takes whatever bytes remain
+									fieldData = data[lowerBound:]
+								} else if upperBound > len(data) {
+									err := errors.Errorf("flatfile.Unmarshal: Record of length %d ends before the field ends at col %d", len(data), upperBound+colOffset)
+									return &FieldError{Field: field.name, Col: ffpTag.col, Length: ffpTag.length, Value: string(data[lowerBound:]), Err: err}
+								} else {
+									fieldData = data[lowerBound:upperBound]
+								}
+								if raw != nil {
+									raw[field.name] = fieldData
+								}
+								if ffpTag.constChk {
+									if actual := strings.TrimSpace(string(fieldOpts.textData(fieldData))); actual != ffpTag.constVal {
+										err := errors.Errorf("flatfile.Unmarshal: Expected constant %q but got %q", ffpTag.constVal, actual)
+										return &FieldError{Field: field.name, Col: ffpTag.col, Length: ffpTag.length, Value: string(fieldData), Err: err}
+									}
+								}
+								if ffpTag.nullChk {
+									isNull := strings.TrimSpace(string(fieldOpts.textData(fieldData))) == ffpTag.nullVal
+									setNullIndicator(vStruct, field.name, isNull)
+									if isNull {
+										fieldValue.Set(reflect.Zero(fieldType))
+										continue
+									}
+								}
+								assignOpts := fieldOpts
+								if i == encodingIdx {
+									assignOpts = o
+								}
+								err := assignBasedOnKind(fieldType.Kind(), fieldValue, fieldData, ffpTag, assignOpts)
 								if err != nil {
-									return errors.Wrap(err, "flatfile.Unmarshal: Failed to unmarshal")
+									fieldErr := &FieldError{Field: field.name, Col: ffpTag.col, Length: ffpTag.length, Value: string(fieldData), Err: err}
+									if !o.warn(fieldType, fieldErr) {
+										return fieldErr
+									}
+									fieldValue.Set(reflect.Zero(fieldType))
+									continue
+								}
+								if ffpTag.signField != "" {
+									if err := applySignField(fieldValue, data, colOffset, layout, field); err != nil {
+										return &FieldError{Field: field.name, Col: ffpTag.col, Length: ffpTag.length, Value: string(fieldData), Err: err}
+									}
 								}
+								if ffpTag.timeField != "" && fieldType == timeType {
+									if err := applyTimeField(fieldValue, fieldData, data, colOffset, layout, field); err != nil {
+										return &FieldError{Field: field.name, Col: ffpTag.col, Length: ffpTag.length, Value: string(fieldData), Err: err}
+									}
+								}
+								applyTransforms(fieldValue, ffpTag.transforms)
 							}
 						}
 					}
@@ -85,74 +292,253 @@ func Unmarshal(data []byte, v interface{}, startFieldIdx int, numFieldsToUnmarsh
 	return errors.Errorf("flatfile.Unmarshal: Unmarshal not complete. %s is not a pointer", reflect.TypeOf(v))
 }
 
-//CalcNumFieldsToUnmarshal determines how many fields can be unmarshalled successfully
-//This currently will not return an accurate result for overlapping fields
-//For example:
-//type Profile struct {
-//		FirstName string `flatfile:"1,10"`
-//		LastName  string `flatfile:"11,10"`
-//		FullName  string `flatfile:"1,20"`
-//}
-//Expected output is that 3 fields can be unmarshalled successfully
-//The result will be 2
-//Another example:
-//type Profile struct
+//unmarshalAllStrings slices each column of data directly into the string fields of vStruct
+func unmarshalAllStrings(data []byte, vStruct reflect.Value, layout *structLayout) error {
+	for i := range layout.fields {
+		if !layout.fields[i].tagged {
+			continue
+		}
+		ffpTag := &layout.fields[i].tag
+		lowerBound := ffpTag.col - 1
+		if lowerBound < len(data) {
+			upperBound := lowerBound + ffpTag.length
+			if upperBound > len(data) {
+				err := errors.Errorf("flatfile.Unmarshal: Record of length %d ends before the field ends at col %d", len(data), upperBound)
+				return &FieldError{Field: vStruct.Type().Field(i).Name, Col: ffpTag.col, Length: ffpTag.length, Value: string(data[lowerBound:]), Err: err}
+			}
+			vStruct.Field(i).SetString(string(data[lowerBound:upperBound]))
+		}
+	}
+	return nil
+}
+
+//UnmarshalFields unmarshals only the named struct fields of v from their declared columns in data
+//Fields are looked up by struct field name and every other field is left untouched
+func UnmarshalFields(data []byte, v interface{}, fieldNames ...string) error {
+	vType := reflect.TypeOf(v)
+	if vType == nil || vType.Kind() != reflect.Ptr || vType.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("flatfile.UnmarshalFields: %v is not a pointer to a struct", vType)
+	}
+	layout := cachedStructLayout(vType.Elem())
+	o := newUnmarshalOptions(nil)
+	for _, fieldName := range fieldNames {
+		structField, exists := vType.Elem().FieldByName(fieldName)
+		if !exists || len(structField.Index) != 1 {
+			return errors.Errorf("flatfile.UnmarshalFields: %s has no field %s", vType.Elem(), fieldName)
+		}
+		fieldIdx := structField.Index[0]
+		if !layout.fields[fieldIdx].tagged {
+			return errors.Errorf("flatfile.UnmarshalFields: Field %s has no flatfile tag", fieldName)
+		}
+		if err := unmarshal(data, v, fieldIdx, 1, false, o); err != nil {
+			return errors.Wrapf(err, "flatfile.UnmarshalFields: Failed to unmarshal field %s", fieldName)
+		}
+	}
+	return nil
+}
+
+//FieldCount describes how much of a buffered record is present, as returned by CountFields
+type FieldCount struct {
+	//FieldsParsable is the number of tagged fields from the field offset on that can be unmarshalled from the data
+	FieldsParsable int
+	//BytesConsumed is the number of bytes of data before Remainder
+	BytesConsumed int
+	//Remainder is the data following the last field that can be unmarshalled, to be carried over to the next buffered read
+	Remainder []byte
+	//Complete is true when every tagged field from the field offset on can be unmarshalled
+	Complete bool
+}
+
+//newFieldCount returns the FieldCount of numFields fields whose data ends before data[consumed:]
+func newFieldCount(numFields int, data []byte, consumed int, complete bool) FieldCount {
+	return FieldCount{FieldsParsable: numFields, BytesConsumed: consumed, Remainder: data[consumed:], Complete: complete}
+}
+
+//CountFields determines how many fields of v from fieldOffset on can be unmarshalled from data and what remains to carry over
+//Fields are counted in declaration order until one does not fit. Overlapping fields that view the same bytes are supported
+//For example with 15 bytes of data:
 //type Profile struct {
 //		FirstName string `flatfile:"1,10"`
 //		LastName  string `flatfile:"11,10"`
 //		FullName  string `flatfile:"1,20"`
-//		Random    string `flatfile:"7,9"`
 //}
-//This function would have to be redesigned to handle multiple scenarios of overlapping fields
-func CalcNumFieldsToUnmarshal(data []byte, v interface{}, fieldOffset int) (int, []byte, error) {
-	ffpTag := &flatfileTag{}
+//1 field can be unmarshalled. The remainder starts at column 1 as FullName still needs the bytes of FirstName
+//A partial Unmarshal of the remainder from field 1 then sets both LastName and FullName
+func CountFields(data []byte, v interface{}, fieldOffset int) (FieldCount, error) {
 	dataLen := len(data)
 	numFieldsToUnmarshal := 0
-	var remainder []byte
-	cumulativeRecLength := 0
-	if reflect.TypeOf(v).Kind() == reflect.Ptr {
+	//coveredLen is the number of bytes covered by the fields counted so far
+	coveredLen := 0
+	if reflect.TypeOf(v) != nil && reflect.TypeOf(v).Kind() == reflect.Ptr {
 		//Get underlying type
 		vType := reflect.TypeOf(v).Elem()
 
 		//Only process if kind is Struct
 		if vType.Kind() == reflect.Struct {
-			//Dereference pointer to struct
-			vStruct := reflect.ValueOf(v).Elem()
+			if fieldOffset < 0 {
+				return FieldCount{}, errors.Errorf("flatfile.CountFields: Out of range error. fieldOffset %d cannot be less than 0", fieldOffset)
+			}
+			layout := cachedStructLayout(vType)
+			//data starts at the first column needed by the fields from fieldOffset on, as it does for a partial Unmarshal
+			colOffset := layout.firstColFrom(fieldOffset) - 1
 
 			//Loop through struct fields/properties
-			for i := fieldOffset; i < vStruct.NumField(); i++ {
+			for i := fieldOffset; i < vType.NumField(); i++ {
+				if !layout.fields[i].tagged {
+					continue
+				}
+				if layout.fields[i].err != nil {
+					return FieldCount{}, errors.Wrapf(layout.fields[i].err, "flatfile.CountFields: Field %s has invalid tag %s", vType.Field(i).Name, layout.fields[i].rawTag)
+				}
 
 				//Get underlying type of field
-				fieldType := vStruct.Field(i).Type()
+				fieldType := vType.Field(i).Type
+				ffpTag := &layout.fields[i].tag
+				//each field starts at its own column regardless of the fields before it
+				fieldStart := ffpTag.col - 1 - colOffset
+				fieldEnd := fieldStart
 
-				fieldTag, tagFlag := vType.Field(i).Tag.Lookup("flatfile")
-				if tagFlag {
-
-					tagParseErr := parseFlatfileTag(fieldTag, ffpTag)
-					if tagParseErr != nil {
-						return 0, []byte(""), errors.Wrapf(tagParseErr, "flatfile.CalcNumFieldsToUnmarshal: Failed to parse field tag %s", fieldTag)
+				if ffpTag.lenPrefix {
+					//the field is the prefix plus the length it declares, which can only be known once the prefix is present
+					if fieldStart+ffpTag.length <= dataLen {
+						value, prefixErr := splitLengthPrefix(data[fieldStart:], ffpTag.length)
+						if prefixErr != nil {
+							return FieldCount{}, errors.Wrap(prefixErr, "flatfile.CountFields: Failed to read length prefix")
+						}
+						fieldEnd += len(value)
 					}
-
-					if ffpTag.occurs > 0 {
-						cumulativeRecLength += ffpTag.length * ffpTag.occurs
-					} else if fieldType.Kind() == reflect.Array {
-						cumulativeRecLength += ffpTag.length * vStruct.Field(i).Len()
-					} else {
-						cumulativeRecLength += ffpTag.length
+					fieldEnd += ffpTag.length
+				} else if ffpTag.occursCol > 0 {
+					//the count must be present in data before the width of the field is known
+					occursTag, occursErr := resolveOccursAt(data, colOffset, fieldType, ffpTag)
+					if occursErr != nil {
+						return newFieldCount(numFieldsToUnmarshal, data, coveredLen, false), nil
 					}
-
-					if cumulativeRecLength <= dataLen {
-						numFieldsToUnmarshal++
-					} else {
-						remainder = data[(cumulativeRecLength - ffpTag.length):]
-						break
+					fieldEnd += occursTag.occurs * elementWidth(fieldType.Elem(), occursTag)
+				} else if ffpTag.occurs == greedyOccurs {
+					//a greedy field consumes every remaining whole occurrence
+					if fieldStart < dataLen {
+						fieldEnd += (dataLen - fieldStart) / ffpTag.length * ffpTag.length
 					}
+				} else {
+					fieldEnd += fieldWidth(fieldType, ffpTag)
+				}
+
+				if fieldEnd > dataLen {
+					//the remainder must hold every byte the fields not yet counted need, including bytes they share with counted fields
+					return newFieldCount(numFieldsToUnmarshal, data, min(layout.firstColFrom(i)-1-colOffset, dataLen), false), nil
 				}
+				numFieldsToUnmarshal++
+				coveredLen = max(coveredLen, fieldEnd)
 			}
 		}
-		return numFieldsToUnmarshal, remainder, nil
+		//the remainder starts at the first byte not covered by a counted field
+		return newFieldCount(numFieldsToUnmarshal, data, coveredLen, true), nil
 	}
-	return 0, []byte(""), errors.Errorf("flatfile.CalcNumFieldsToUnmarshal: CalcNumFieldsToUnmarshal not complete. %s is not a pointer", reflect.TypeOf(v))
+	return FieldCount{}, errors.Errorf("flatfile.CountFields: CountFields not complete. %s is not a pointer", reflect.TypeOf(v))
+}
+
+//CalcNumFieldsToUnmarshal determines how many fields can be unmarshalled successfully
+//The remainder returned is the data following the last field that can be unmarshalled, to be carried over to the next buffered read
+//See CountFields, which also reports whether the record is complete
+func CalcNumFieldsToUnmarshal(data []byte, v interface{}, fieldOffset int) (int, []byte, error) {
+	count, err := CountFields(data, v, fieldOffset)
+	if err != nil {
+		return 0, []byte(""), errors.Wrap(err, "flatfile.CalcNumFieldsToUnmarshal: Failed to count fields")
+	}
+	return count.FieldsParsable, count.Remainder, nil
+}
+
+//applySignField negates field when the sign field named by its signField option holds a negative sign
+func applySignField(field reflect.Value, data []byte, colOffset int, layout *structLayout, signed *fieldLayout) error {
+	signData, err := fieldRefData(data, colOffset, &layout.fields[signed.signIdx])
+	if err != nil {
+		return errors.Wrapf(err, "flatfile.applySignField: Sign field %s", signed.tag.signField)
+	}
+	return errors.Wrapf(applySign(field, signData), "flatfile.applySignField: Sign field %s", signed.tag.signField)
+}
+
+//applyTimeField sets the time of day of a time.Time date field from the field named by its timeField option
+//The time is parsed with the layout of the time field. A blank time leaves the date at midnight
+func applyTimeField(field reflect.Value, dateData []byte, data []byte, colOffset int, layout *structLayout, dated *fieldLayout) error {
+	timeLayout := &layout.fields[dated.timeIdx]
+	timeData, err := fieldRefData(data, colOffset, timeLayout)
+	if err != nil {
+		return errors.Wrapf(err, "flatfile.applyTimeField: Time field %s", dated.tag.timeField)
+	}
+	if timeLayout.tag.layout == "" {
+		return errors.Errorf("flatfile.applyTimeField: Time field %s requires a layout option e.g. `layout=150405`", dated.tag.timeField)
+	}
+	date, clock := strings.TrimSpace(string(dateData)), strings.TrimSpace(string(timeData))
+	if date == "" || clock == "" {
+		return nil
+	}
+	t, err := time.Parse(dated.tag.layout+" "+timeLayout.tag.layout, date+" "+clock)
+	if err != nil {
+		return errors.Wrapf(err, "flatfile.applyTimeField: Failed to parse time %q of field %s with layout %s", clock, dated.tag.timeField, timeLayout.tag.layout)
+	}
+	field.Set(reflect.ValueOf(t))
+	return nil
+}
+
+//fieldRefData returns the data of the field referenced by a signField or timeField option
+func fieldRefData(data []byte, colOffset int, ref *fieldLayout) ([]byte, error) {
+	if ref.err != nil {
+		return nil, errors.Wrapf(ref.err, "flatfile.fieldRefData: Invalid tag %s", ref.rawTag)
+	}
+	lowerBound := ref.tag.col - 1 - colOffset
+	upperBound := lowerBound + ref.tag.length
+	if lowerBound < 0 || upperBound > len(data) {
+		return nil, errors.New("flatfile.fieldRefData: Field is outside of the data")
+	}
+	return data[lowerBound:upperBound], nil
+}
+
+//nullIndicatorSuffix is appended to a field name to find the bool field that records whether it was null
+const nullIndicatorSuffix = "IsNull"
+
+//setNullIndicator sets the bool field named fieldName+"IsNull" of vStruct, if there is one, to isNull
+func setNullIndicator(vStruct reflect.Value, fieldName string, isNull bool) {
+	indicator := vStruct.FieldByName(fieldName + nullIndicatorSuffix)
+	if indicator.IsValid() && indicator.Kind() == reflect.Bool && indicator.CanSet() {
+		indicator.SetBool(isNull)
+	}
+}
+
+//resolveOccursAt returns a copy of ffpTag with occurs read from the count located by occursAt in data
+//A count of more elements of fieldType than remain in data is an error, so a corrupt count cannot allocate a huge slice
+//The count is decimal digits, or a big-endian unsigned integer when occursAt ends in :binary
+func resolveOccursAt(data []byte, colOffset int, fieldType reflect.Type, ffpTag *flatfileTag) (*flatfileTag, error) {
+	lowerBound := ffpTag.occursCol - 1 - colOffset
+	upperBound := lowerBound + ffpTag.occursLen
+	if lowerBound < 0 || upperBound > len(data) {
+		return nil, errors.Errorf("flatfile.resolveOccursAt: occursAt %d:%d is outside of the data", ffpTag.occursCol, ffpTag.occursLen)
+	}
+	var occurs int
+	if ffpTag.occursBinary {
+		for _, b := range data[lowerBound:upperBound] {
+			occurs = occurs<<8 | int(b)
+		}
+	} else {
+		var err error
+		occurs, err = strconv.Atoi(strings.TrimSpace(string(data[lowerBound:upperBound])))
+		if err != nil {
+			return nil, errors.Wrapf(err, "flatfile.resolveOccursAt: Error parsing occurs count at %d:%d", ffpTag.occursCol, ffpTag.occursLen)
+		}
+	}
+	if occurs < 0 {
+		return nil, errors.Errorf("flatfile.resolveOccursAt: Occurs count %d at %d:%d cannot be negative", occurs, ffpTag.occursCol, ffpTag.occursLen)
+	}
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	remaining := max(len(data)-(ffpTag.col-1-colOffset), 0)
+	if elemWidth := elementWidth(fieldType.Elem(), ffpTag); elemWidth > 0 && occurs > remaining/elemWidth {
+		return nil, errors.Errorf("flatfile.resolveOccursAt: Occurs count %d at %d:%d needs more than the %d bytes remaining", occurs, ffpTag.occursCol, ffpTag.occursLen, remaining)
+	}
+	occursTag := *ffpTag
+	occursTag.occurs = occurs
+	return &occursTag, nil
 }
 
 //ShouldUnmarshal returns true if the condition
@@ -160,6 +546,10 @@ func ShouldUnmarshal(ffpTag *flatfileTag, data []byte) bool {
 	if ffpTag.condChk {
 		lowerBound := ffpTag.condCol - 1
 		upperBound := lowerBound + ffpTag.condLen
+		//a condition outside of the data cannot match
+		if lowerBound < 0 || upperBound > len(data) {
+			return false
+		}
 		return string(data[lowerBound:upperBound]) == ffpTag.condVal
 	}
 
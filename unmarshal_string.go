@@ -0,0 +1,10 @@
+package flatfile
+
+//UnmarshalString behaves like Unmarshal for data held in a string without copying data to a []byte
+//String fields assigned to v are still copies so they do not retain data
+//Callbacks, converters and FieldUnmarshalers are given a copy of their field data as the view of data must not be modified
+func UnmarshalString(data string, v interface{}, startFieldIdx int, numFieldsToUnmarshal int, opts ...Option) error {
+	o := newUnmarshalOptions(opts)
+	o.readOnly = true
+	return unmarshalData(stringBytes(data), v, startFieldIdx, numFieldsToUnmarshal, false, o)
+}
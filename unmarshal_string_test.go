@@ -0,0 +1,70 @@
+package flatfile
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalString(t *testing.T) {
+	type FfpTest struct {
+		Name string `flatfile:"1,3"`
+		Age  int    `flatfile:"4,2"`
+		Note string `flatfile:"6,4"`
+	}
+
+	got := &FfpTest{}
+	err := UnmarshalString("AMY19NOTE", got, 0, 0)
+	if err != nil {
+		t.Error(err)
+	}
+
+	want := FfpTest{Name: "AMY", Age: 19, Note: "NOTE"}
+	if *got != want {
+		t.Errorf("UnmarshalString(AMY19NOTE) got: %v want: %v", *got, want)
+	}
+}
+
+func TestUnmarshalStringStartFieldIdx(t *testing.T) {
+	type FfpTest struct {
+		Name string `flatfile:"1,3"`
+		Age  int    `flatfile:"4,2"`
+	}
+
+	got := &FfpTest{}
+	err := UnmarshalString("AMY19", got, 1, 1)
+	if err != nil {
+		t.Error(err)
+	}
+
+	want := FfpTest{Age: 19}
+	if *got != want {
+		t.Errorf("UnmarshalString(AMY19,1,1) got: %v want: %v", *got, want)
+	}
+}
+
+func TestUnmarshalStringUserCodeCopy(t *testing.T) {
+	data := strings.Repeat("AMY", 2)
+	got := struct {
+		Hook  func([]byte) error `flatfile:"1,3"`
+		Field testLowerField     `flatfile:"4,3"`
+	}{Hook: func(fieldData []byte) error {
+		fieldData[0] = 'X'
+		return nil
+	}}
+	if err := UnmarshalString(data, &got, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	//user code may modify its field data without changing the string
+	if data != "AMYAMY" {
+		t.Errorf("UnmarshalString modified its data got: %q want: AMYAMY", data)
+	}
+}
+
+type testLowerField string
+
+func (f *testLowerField) UnmarshalFlatfileField(fieldData []byte) error {
+	copy(fieldData, bytes.ToLower(fieldData))
+	*f = testLowerField(fieldData)
+	return nil
+}
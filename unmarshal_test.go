@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -96,6 +97,73 @@ func TestBoolErr_Unmarshal(t *testing.T) {
 	t.Log(err)
 }
 
+func TestBoolPadded_Unmarshal(t *testing.T) {
+	type BoolStruct struct {
+		Flag   bool `flatfile:"1,2"`
+		Mapped bool `flatfile:"3,2,true=Y,false=N"`
+	}
+
+	var tests = []struct {
+		Record  []byte
+		Want    BoolStruct
+		isError bool
+	}{
+		{[]byte("1 Y "), BoolStruct{Flag: true, Mapped: true}, false},
+		{[]byte(" tN "), BoolStruct{Flag: true, Mapped: false}, false},
+		{[]byte("   N"), BoolStruct{Flag: false, Mapped: false}, false},
+		{[]byte("F   "), BoolStruct{Flag: false, Mapped: false}, false},
+		{[]byte("  X "), BoolStruct{}, true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestBoolPadded_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := BoolStruct{Flag: true, Mapped: true}
+			err := Unmarshal(tt.Record, &got, 0, 0, false)
+			if (err != nil) != tt.isError {
+				t.Errorf("Unmarshal(%q) err: %v want error: %v", string(tt.Record), err, tt.isError)
+			}
+			if err == nil && got != tt.Want {
+				t.Errorf("Unmarshal(%q) got: %v want: %v", string(tt.Record), got, tt.Want)
+			}
+		})
+	}
+}
+
+func TestBoolNumeric_Unmarshal(t *testing.T) {
+	type BoolStruct struct {
+		Flag    bool `flatfile:"1,2"`
+		Numeric bool `flatfile:"3,3,boolmode=numeric"`
+	}
+
+	var tests = []struct {
+		Record  []byte
+		Want    BoolStruct
+		isError bool
+	}{
+		{[]byte("01  1"), BoolStruct{Flag: true, Numeric: true}, false},
+		{[]byte(" 1 25"), BoolStruct{Flag: true, Numeric: true}, false},
+		{[]byte("00000"), BoolStruct{Flag: false, Numeric: false}, false},
+		{[]byte(" 0-.5"), BoolStruct{Flag: false, Numeric: true}, false},
+		{[]byte("02001"), BoolStruct{}, true},
+		{[]byte("01 Y "), BoolStruct{}, true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestBoolNumeric_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := BoolStruct{}
+			err := Unmarshal(tt.Record, &got, 0, 0, false)
+			if (err != nil) != tt.isError {
+				t.Errorf("Unmarshal(%q) err: %v want error: %v", string(tt.Record), err, tt.isError)
+			}
+			if err == nil && got != tt.Want {
+				t.Errorf("Unmarshal(%q) got: %v want: %v", string(tt.Record), got, tt.Want)
+			}
+		})
+	}
+}
+
 func TestUint8_Unmarshal(t *testing.T) {
 	type Uint8Struct struct {
 		Uint8One uint8 `flatfile:"1,1"`
@@ -658,6 +726,76 @@ func TestFloat64_Unmarshal(t *testing.T) {
 	}
 }
 
+func TestFloatMoney_Unmarshal(t *testing.T) {
+	type MoneyStruct struct {
+		Amount   float64 `flatfile:"1,12,,money"`
+		Amount32 float32 `flatfile:"1,12,money"`
+	}
+
+	var tests = []struct {
+		Record  string
+		Want    float64
+		WantErr bool
+	}{
+		{"   $1,234.56", 1234.56, false},
+		{"(1,234.56)  ", -1234.56, false},
+		{"($1,234.56) ", -1234.56, false},
+		{"-$1,234.56  ", -1234.56, false},
+		{"€1,234.50  ", 1234.5, false},
+		{"1234.5      ", 1234.5, false},
+		{"(1,234.56   ", 0, true},
+		{"$abc        ", 0, true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestFloatMoney_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			testVal := &MoneyStruct{}
+			err := Unmarshal([]byte(tt.Record), testVal, 0, 0, false)
+			if (err != nil) != tt.WantErr {
+				t.Fatalf("Unmarshal(%s,0,0,false) err: %v want err: %v", tt.Record, err, tt.WantErr)
+			}
+			if testVal.Amount != tt.Want || testVal.Amount32 != float32(tt.Want) {
+				t.Errorf("Unmarshal(%s,0,0,false) got: %v %v want: %v", tt.Record, testVal.Amount, testVal.Amount32, tt.Want)
+			}
+		})
+	}
+}
+
+func TestNumericWhitespace_Unmarshal(t *testing.T) {
+	type NumberStruct struct {
+		Int   int     `flatfile:"1,6"`
+		Uint  uint16  `flatfile:"7,6"`
+		Float float64 `flatfile:"13,8"`
+	}
+
+	var tests = []struct {
+		Record  string
+		Want    NumberStruct
+		WantErr bool
+	}{
+		{"    42    42    1.25", NumberStruct{42, 42, 1.25}, false},
+		{"\t-42\t \t42\t\t\t1.25\t\t\t\t", NumberStruct{-42, 42, 1.25}, false},
+		{"\u00a042\u00a0 \u00a042 \u00a01.25\u00a0", NumberStruct{42, 42, 1.25}, false},
+		{"\n\r\v42\f\v\f42\n\n\n1.25\r\n\n", NumberStruct{42, 42, 1.25}, false},
+		{"4 2   42    1.25", NumberStruct{}, true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestNumericWhitespace_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			testVal := &NumberStruct{}
+			err := Unmarshal([]byte(tt.Record), testVal, 0, 0, false)
+			if (err != nil) != tt.WantErr {
+				t.Fatalf("Unmarshal(%q,0,0,false) err: %v want err: %v", tt.Record, err, tt.WantErr)
+			}
+			if !tt.WantErr && *testVal != tt.Want {
+				t.Errorf("Unmarshal(%q,0,0,false) got: %+v want: %+v", tt.Record, *testVal, tt.Want)
+			}
+		})
+	}
+}
+
 func TestFloat64InvalidSyntaxErr_Unmarshal(t *testing.T) {
 	type Float64Struct struct {
 		Float64One float64 `flatfile:"1,1"`
@@ -777,6 +915,38 @@ func TestFfpTagParseMissingParamErr_Unmarshal(t *testing.T) {
 	t.Log(err)
 }
 
+func TestFfpTagErrFieldName_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Name       string `flatfile:"1,3"`
+		CustomerID string `flatfile:"0,5"`
+		Age        int    `flatfile:"9,0"`
+	}
+
+	var tests = []struct {
+		StartFieldIdx int
+		WantMsg       string
+	}{
+		{0, "Field CustomerID has invalid tag 0,5"},
+		{1, "Column parameter 0 cannot be less than 1. Please note column is 1-indexed not zero"},
+		{2, "Field Age has invalid tag 9,0"},
+		{2, "Length parameter 0 cannot be less than 1"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestFfpTagErrFieldName_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			err := Unmarshal([]byte("AMY1234519"), &FfpTest{}, tt.StartFieldIdx, 0, false)
+			if err == nil || !strings.Contains(err.Error(), tt.WantMsg) {
+				t.Errorf("Unmarshal err: %v want message containing: %s", err, tt.WantMsg)
+			}
+			_, _, err = CalcNumFieldsToUnmarshal([]byte("AMY1234519"), &FfpTest{}, tt.StartFieldIdx)
+			if err == nil || !strings.Contains(err.Error(), tt.WantMsg) {
+				t.Errorf("CalcNumFieldsToUnmarshal err: %v want message containing: %s", err, tt.WantMsg)
+			}
+		})
+	}
+}
+
 func TestArrayParse(t *testing.T) {
 	type FfpTest struct {
 		TestVal [4]int     `flatfile:"1,2"`
@@ -907,6 +1077,209 @@ func TestSliceNestedStructParse(t *testing.T) {
 	}
 }
 
+func TestTwoDimensionalParse(t *testing.T) {
+	type Cell struct {
+		Val string `flatfile:"2,1"`
+	}
+	type FfpTest struct {
+		SliceOfSlices [][]int    `flatfile:"1,2,2x3"`
+		ArrayOfArrays [2][3]int  `flatfile:"1,2"`
+		SliceOfArrays [][3]int   `flatfile:"1,2,2"`
+		ArrayOfSlices [2][]int   `flatfile:"1,2,2x3"`
+		Greedy        [][]string `flatfile:"1,2,-1x3"`
+		Cells         [][]Cell   `flatfile:"13,2,2x2"`
+		Names         [][]string `flatfile:"13,1,2x4"`
+	}
+
+	testVal := &FfpTest{}
+	data := []byte("112233445566AbCdEfGh")
+
+	err := Unmarshal(data, testVal, 0, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantRows := [][]int{{11, 22, 33}, {44, 55, 66}}
+	if !reflect.DeepEqual(testVal.SliceOfSlices, wantRows) {
+		t.Errorf("SliceOfSlices got: %v want: %v", testVal.SliceOfSlices, wantRows)
+	}
+	if testVal.ArrayOfArrays != [2][3]int{{11, 22, 33}, {44, 55, 66}} {
+		t.Errorf("ArrayOfArrays got: %v want: %v", testVal.ArrayOfArrays, wantRows)
+	}
+	if !reflect.DeepEqual(testVal.SliceOfArrays, [][3]int{{11, 22, 33}, {44, 55, 66}}) {
+		t.Errorf("SliceOfArrays got: %v want: %v", testVal.SliceOfArrays, wantRows)
+	}
+	if !reflect.DeepEqual(testVal.ArrayOfSlices, [2][]int{{11, 22, 33}, {44, 55, 66}}) {
+		t.Errorf("ArrayOfSlices got: %v want: %v", testVal.ArrayOfSlices, wantRows)
+	}
+	if !reflect.DeepEqual(testVal.Cells, [][]Cell{{{"b"}, {"d"}}, {{"f"}, {"h"}}}) {
+		t.Errorf("Cells got: %v", testVal.Cells)
+	}
+	if !reflect.DeepEqual(testVal.Names, [][]string{{"A", "b", "C", "d"}, {"E", "f", "G", "h"}}) {
+		t.Errorf("Names got: %v", testVal.Names)
+	}
+	wantGreedy := [][]string{{"11", "22", "33"}, {"44", "55", "66"}, {"Ab", "Cd", "Ef"}}
+	if !reflect.DeepEqual(testVal.Greedy, wantGreedy) {
+		t.Errorf("Greedy got: %v want: %v", testVal.Greedy, wantGreedy)
+	}
+}
+
+func TestTwoDimensionalMissingColumnsErr(t *testing.T) {
+	type FfpTest struct {
+		SliceOfSlices [][]int `flatfile:"1,2,2"`
+	}
+
+	err := Unmarshal([]byte("112233445566"), &FfpTest{}, 0, 0, false)
+	if err == nil {
+		t.Error("Unmarshal should return an error when a slice of slices has no columns")
+	}
+	t.Log(err)
+}
+
+func TestSingleOccursSliceParse(t *testing.T) {
+	type FfpTest struct {
+		Names []string `flatfile:"1,3,1"`
+	}
+
+	testVal := &FfpTest{}
+	err := Unmarshal([]byte("AMYBOB"), testVal, 0, 0, false)
+	if err != nil {
+		t.Errorf("err: %s", err)
+	}
+	if !reflect.DeepEqual(testVal.Names, []string{"AMY"}) {
+		t.Errorf("Unmarshal(AMYBOB) got: %v want: [AMY]", testVal.Names)
+	}
+}
+
+func TestGreedySliceParse(t *testing.T) {
+	type FfpTest struct {
+		Count int      `flatfile:"1,1"`
+		Names []string `flatfile:"2,3,-1"`
+	}
+
+	var tests = []struct {
+		Record []byte
+		Want   []string
+	}{
+		{[]byte("3AMYBOBCAM"), []string{"AMY", "BOB", "CAM"}},
+		{[]byte("1AMY"), []string{"AMY"}},
+		{[]byte("2AMYBOBCA"), []string{"AMY", "BOB"}},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestGreedySliceParse-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			testVal := &FfpTest{}
+			err := Unmarshal(tt.Record, testVal, 0, 0, false)
+			if err != nil {
+				t.Errorf("err: %s", err)
+			}
+			if !reflect.DeepEqual(testVal.Names, tt.Want) {
+				t.Errorf("Unmarshal(%s,0,0,false) got: %v want: %v", string(tt.Record), testVal.Names, tt.Want)
+			}
+		})
+	}
+}
+
+func TestOccursAtSliceParse(t *testing.T) {
+	type FfpTest struct {
+		ID    string   `flatfile:"1,2"`
+		Names []string `flatfile:"5,3,occursAt=3:2"`
+		Next  []string `flatfile:"5,1,occursAt=3:2"`
+	}
+
+	var tests = []struct {
+		Record  []byte
+		Want    []string
+		WantErr bool
+	}{
+		{[]byte("A103AMYBOBCAM"), []string{"AMY", "BOB", "CAM"}, false},
+		{[]byte("A1 1AMY"), []string{"AMY"}, false},
+		{[]byte("A100   "), []string{}, false},
+		{[]byte("A1XXAMY"), nil, true},
+		{[]byte("A1-1AMY"), nil, true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestOccursAtSliceParse-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			testVal := &FfpTest{}
+			err := Unmarshal(tt.Record, testVal, 0, 0, false)
+			if (err != nil) != tt.WantErr {
+				t.Fatalf("Unmarshal(%s,0,0,false) err: %v want err: %v", string(tt.Record), err, tt.WantErr)
+			}
+			if !tt.WantErr && !reflect.DeepEqual(testVal.Names, tt.Want) {
+				t.Errorf("Unmarshal(%s,0,0,false) got: %v want: %v", string(tt.Record), testVal.Names, tt.Want)
+			}
+		})
+	}
+}
+
+func TestOccursAtBinarySliceParse(t *testing.T) {
+	type FfpTest struct {
+		ID    string   `flatfile:"1,2"`
+		Names []string `flatfile:"5,3,occursAt=3:2:binary"`
+	}
+
+	var tests = []struct {
+		Record  []byte
+		Want    []string
+		WantErr bool
+	}{
+		{append([]byte("A1\x00\x03"), "AMYBOBCAM"...), []string{"AMY", "BOB", "CAM"}, false},
+		{append([]byte("A1\x00\x00"), "   "...), []string{}, false},
+		{append([]byte("A1\x01\x00"), "AMY"...), nil, true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestOccursAtBinarySliceParse-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			testVal := &FfpTest{}
+			err := Unmarshal(tt.Record, testVal, 0, 0, false)
+			if (err != nil) != tt.WantErr {
+				t.Fatalf("Unmarshal(%q,0,0,false) err: %v want err: %v", tt.Record, err, tt.WantErr)
+			}
+			if !tt.WantErr && !reflect.DeepEqual(testVal.Names, tt.Want) {
+				t.Errorf("Unmarshal(%q,0,0,false) got: %v want: %v", tt.Record, testVal.Names, tt.Want)
+			}
+		})
+	}
+
+	for _, tag := range []string{"5,3,occursAt=3:2:packed", "5,3,occursAt=3:5:binary", "5,3,occursAt=3:2:binary:x"} {
+		if err := parseFlatfileTag(tag, &flatfileTag{}); err == nil {
+			t.Errorf("parseFlatfileTag(%s) should return an error", tag)
+		}
+	}
+}
+
+func TestOccursAtCountTooLarge(t *testing.T) {
+	var tests = []struct {
+		Record []byte
+		V      interface{}
+	}{
+		{[]byte("999999999ab"), &struct {
+			X []string `flatfile:"10,1,occursAt=1:9"`
+		}{}},
+		{[]byte("\xFF\xFF\xFF\xFFab"), &struct {
+			X []string `flatfile:"5,1,occursAt=1:4:binary"`
+		}{}},
+		{[]byte("03ABCD"), &struct {
+			X *[]string `flatfile:"3,2,occursAt=1:2"`
+		}{}},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestOccursAtCountTooLarge-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			//the count is checked against the data before the slice is allocated, even when the last field may be partial
+			err := Unmarshal(tt.Record, tt.V, 0, 0, false, WithPartialLastField())
+			if err == nil || !strings.Contains(err.Error(), "bytes remaining") {
+				t.Errorf("Unmarshal(%q) err: %v want message containing: bytes remaining", tt.Record, err)
+			}
+		})
+	}
+}
+
 func TestOffsetParse(t *testing.T) {
 	type Name struct {
 		NameData     string `flatfile:"1,3"`
@@ -968,6 +1341,35 @@ func TestCalcNumFieldsToUnmarshal(t *testing.T) {
 		})
 	}
 }
+func TestCalcNumFieldsToUnmarshalGreedy(t *testing.T) {
+	type Profile struct {
+		Count int      `flatfile:"1,1"`
+		Names []string `flatfile:"2,3,-1"`
+	}
+
+	var tests = []struct {
+		Record        []byte
+		Want          int
+		WantRemainder []byte
+	}{
+		{[]byte("3AMYBOBCAM"), 2, nil},
+		{[]byte("2AMYBOBCA"), 2, []byte("CA")},
+		{[]byte("0"), 2, nil},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("CalcNumFieldsToUnmarshalGreedy-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, remainder, err := CalcNumFieldsToUnmarshal(tt.Record, &Profile{}, 0)
+			if err != nil {
+				t.Errorf("err: %s", err)
+			}
+			if got != tt.Want || !bytes.Equal(remainder, tt.WantRemainder) {
+				t.Errorf("CalcNumFieldsToUnmarshal(%s,0) got: %d %s want: %d %s", string(tt.Record), got, string(remainder), tt.Want, string(tt.WantRemainder))
+			}
+		})
+	}
+}
 func TestCalcNumFieldsToUnmarshalRemainder(t *testing.T) {
 	type Profile struct {
 		NameData string `flatfile:"1,9"`
@@ -985,6 +1387,7 @@ func TestCalcNumFieldsToUnmarshalRemainder(t *testing.T) {
 		{&Profile{}, []byte("1234567"), 0, []byte("1234567")},
 		{&Profile{}, []byte("1"), 1, []byte("1")},
 		{&Profile{}, []byte("12"), 1, []byte("")},
+		{&Profile{}, []byte("1234567891011"), 0, []byte("11")},
 	}
 
 	for idx, tt := range tests {
@@ -1001,6 +1404,81 @@ func TestCalcNumFieldsToUnmarshalRemainder(t *testing.T) {
 	}
 }
 
+func TestCountFields(t *testing.T) {
+	type Profile struct {
+		FirstName string   `flatfile:"1,10"`
+		LastName  string   `flatfile:"11,10"`
+		FullName  string   `flatfile:"1,20"`
+		Codes     []string `flatfile:"23,2,occursAt=21:2"`
+	}
+
+	var tests = []struct {
+		Record      string
+		IndexOffset int
+		Want        FieldCount
+	}{
+		{"AMY       LEE       02ABCDXX", 0, FieldCount{4, 26, []byte("XX"), true}},
+		{"AMY       LEE       02ABCD", 0, FieldCount{4, 26, []byte(""), true}},
+		{"AMY       LEE       02AB", 0, FieldCount{3, 20, []byte("02AB"), false}},
+		{"AMY       LEE", 0, FieldCount{1, 0, []byte("AMY       LEE"), false}},
+		{"LEE       ", 1, FieldCount{0, 0, []byte("LEE       "), false}},
+		{"02ABCD", 3, FieldCount{1, 6, []byte(""), true}},
+		{"", 0, FieldCount{0, 0, []byte(""), false}},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("CountFields-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, err := CountFields([]byte(tt.Record), &Profile{}, tt.IndexOffset)
+			if err != nil || !reflect.DeepEqual(got, tt.Want) {
+				t.Errorf("CountFields(%s,%d) got: %+v err: %v want: %+v", tt.Record, tt.IndexOffset, got, err, tt.Want)
+			}
+		})
+	}
+
+	//the remainder of a field with an occursAt count starts at the count
+	got := &Profile{}
+	if err := Unmarshal([]byte("02ABCD"), got, 3, 0, true); err != nil || !reflect.DeepEqual(got.Codes, []string{"AB", "CD"}) {
+		t.Errorf("Unmarshal of the remainder got: %v err: %v", got.Codes, err)
+	}
+	if _, err := CountFields([]byte("AMY"), Profile{}, 0); err == nil {
+		t.Error("CountFields should return an error when v is not a pointer")
+	}
+}
+
+func TestCalcNumFieldsToUnmarshalRepeatingRemainder(t *testing.T) {
+	type Profile struct {
+		ID     string    `flatfile:"1,2"`
+		Scores []int     `flatfile:"3,2,3"`
+		Codes  [2]string `flatfile:"9,1"`
+		Name   string    `flatfile:"11,4"`
+	}
+
+	var tests = []struct {
+		Record []byte
+		Want   int
+		Remain []byte
+	}{
+		{[]byte("ID1020"), 1, []byte("1020")},
+		{[]byte("ID102030A"), 2, []byte("A")},
+		{[]byte("ID102030ABNA"), 3, []byte("NA")},
+		{[]byte("ID102030ABNAME"), 4, []byte("")},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("CalcNumFieldsToUnmarshalRepeatingRemainder-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, remainder, err := CalcNumFieldsToUnmarshal(tt.Record, &Profile{}, 0)
+			if err != nil {
+				t.Errorf("err: %s", err)
+			}
+			if got != tt.Want || !bytes.Equal(remainder, tt.Remain) {
+				t.Errorf("CalcNumFieldsToUnmarshal(%s,0) got: %d %s want: %d %s", string(tt.Record), got, string(remainder), tt.Want, string(tt.Remain))
+			}
+		})
+	}
+}
+
 func TestByte_Unmarshal(t *testing.T) {
 	type ByteStruct struct {
 		ByteOne byte `flatfile:"1,1,override=byte"`
@@ -1067,6 +1545,56 @@ func TestRune_Unmarshal(t *testing.T) {
 	}
 }
 
+func TestRuneSequence_Unmarshal(t *testing.T) {
+	type RuneStruct struct {
+		Row     []rune   `flatfile:"1,6,override=rune"`
+		Fixed   [3]rune  `flatfile:"7,1,override=rune"`
+		Grid    [][]rune `flatfile:"1,1,2x3,override=rune"`
+		Chunked []rune   `flatfile:"10,1,2,override=rune"`
+	}
+
+	got := RuneStruct{}
+	err := Unmarshal([]byte("#..#.#abc#."), &got, 0, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := RuneStruct{Row: []rune("#..#.#"), Fixed: [3]rune{'a', 'b', 'c'}, Grid: [][]rune{[]rune("#.."), []rune("#.#")}, Chunked: []rune("#.")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal(#..#.#abc#.) got: %q want: %q", got, want)
+	}
+}
+
+func TestRuneSequenceUTF8_Unmarshal(t *testing.T) {
+	type RuneStruct struct {
+		Row   []rune  `flatfile:"1,6,override=rune"`
+		Fixed [3]rune `flatfile:"7,2,override=rune"`
+	}
+
+	var tests = []struct {
+		Record  []byte
+		Want    RuneStruct
+		isError bool
+	}{
+		{[]byte("é..#aß€ "), RuneStruct{Row: []rune("é..#a"), Fixed: [3]rune{'ß', '€', ' '}}, false},
+		{[]byte("ab\xff...xyz"), RuneStruct{}, true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestRuneSequenceUTF8_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := RuneStruct{}
+			err := Unmarshal(tt.Record, &got, 0, 0, false)
+			if (err != nil) != tt.isError {
+				t.Errorf("Unmarshal(%q) err: %v want error: %v", string(tt.Record), err, tt.isError)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.Want) {
+				t.Errorf("Unmarshal(%q) got: %q want: %q", string(tt.Record), got, tt.Want)
+			}
+		})
+	}
+}
+
 func TestStartFieldIdx_Unmarshal(t *testing.T) {
 	type ByteStruct struct {
 		ByteOne byte `flatfile:"1,1,override=byte"`
@@ -1154,13 +1682,81 @@ func TestNestedPointer_Unmarshal(t *testing.T) {
 	}
 }
 
-func TestShouldUnmarshal(t *testing.T) {
+func TestPointerToRepeating_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Names  *[]string `flatfile:"1,3,2"`
+		Digits *[3]int   `flatfile:"7,1"`
+		Rest   *[]string `flatfile:"10,2,-1"`
+	}
 
-	var tests = []struct {
-		ffTag  flatfileTag
-		Record []byte
-		Want   bool
-	}{
+	testVal := &FfpTest{}
+	err := Unmarshal([]byte("AMYBOB123ABCDEF"), testVal, 0, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if testVal.Names == nil || !reflect.DeepEqual(*testVal.Names, []string{"AMY", "BOB"}) {
+		t.Errorf("Names got: %v", testVal.Names)
+	}
+	if testVal.Digits == nil || *testVal.Digits != [3]int{1, 2, 3} {
+		t.Errorf("Digits got: %v", testVal.Digits)
+	}
+	if testVal.Rest == nil || !reflect.DeepEqual(*testVal.Rest, []string{"AB", "CD", "EF"}) {
+		t.Errorf("Rest got: %v", testVal.Rest)
+	}
+}
+
+func TestPointerToNamedNumeric_Unmarshal(t *testing.T) {
+	type Code int
+	type Amount float64
+	type Count uint16
+	type FfpTest struct {
+		Code   *Code   `flatfile:"1,3"`
+		Amount *Amount `flatfile:"4,5"`
+		Count  *Count  `flatfile:"9,2"`
+		Name   *string `flatfile:"11,2"`
+	}
+	code, amount, count, name := Code(42), Amount(1.25), Count(7), "AB"
+	blankName := "  "
+
+	var tests = []struct {
+		Data string
+		Want FfpTest
+	}{
+		{"04201.2507AB", FfpTest{&code, &amount, &count, &name}},
+		{"          ", FfpTest{nil, nil, nil, nil}},
+		{"             ", FfpTest{nil, nil, nil, &blankName}},
+		{"042     07AB", FfpTest{&code, nil, &count, &name}},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestPointerToNamedNumeric_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			//a reused struct has its pointers cleared by blank fields
+			testVal := &FfpTest{Code: new(Code), Amount: new(Amount), Count: new(Count)}
+			if err := Unmarshal([]byte(tt.Data), testVal, 0, 0, false); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*testVal, tt.Want) {
+				t.Errorf("Unmarshal(%s) got: %+v want: %+v", tt.Data, *testVal, tt.Want)
+			}
+		})
+	}
+
+	raw := &struct {
+		Flags *uint8 `flatfile:"1,1,,bitflags"`
+	}{}
+	if err := Unmarshal([]byte(" "), raw, 0, 0, false); err != nil || raw.Flags == nil || *raw.Flags != ' ' {
+		t.Errorf("a blank bitflags pointer should read the space byte got: %v err: %v", raw.Flags, err)
+	}
+}
+
+func TestShouldUnmarshal(t *testing.T) {
+
+	var tests = []struct {
+		ffTag  flatfileTag
+		Record []byte
+		Want   bool
+	}{
 		{flatfileTag{condCol: 1, condLen: 1, condVal: "1", condChk: true}, []byte("1134567891"), true},
 		{flatfileTag{condCol: 1, condLen: 1, condVal: "9", condChk: true}, []byte("1134567891"), false},
 		{flatfileTag{condCol: 4, condLen: 2, condVal: "45", condChk: true}, []byte("1134567891"), true},
@@ -1181,3 +1777,947 @@ func TestShouldUnmarshal(t *testing.T) {
 		})
 	}
 }
+
+func TestLenPrefix_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		ID   string `flatfile:"1,3"`
+		Name string `flatfile:"4,3,,lenPrefix"`
+	}
+
+	var tests = []struct {
+		Record  []byte
+		Want    FfpTest
+		isError bool
+	}{
+		{[]byte("ID1005HELLOXXXX"), FfpTest{ID: "ID1", Name: "HELLO"}, false},
+		{[]byte("ID1000XXXX"), FfpTest{ID: "ID1", Name: ""}, false},
+		{[]byte("ID1009HELLO"), FfpTest{ID: "ID1"}, true},
+		{[]byte("ID1ABCHELLO"), FfpTest{ID: "ID1"}, true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestLenPrefix_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := FfpTest{}
+			err := Unmarshal(tt.Record, &got, 0, 0, false)
+			if (err != nil) != tt.isError {
+				t.Errorf("Unmarshal(%s) err: %v want error: %v", string(tt.Record), err, tt.isError)
+			}
+			if got != tt.Want {
+				t.Errorf("Unmarshal(%s) got: %v want: %v", string(tt.Record), got, tt.Want)
+			}
+		})
+	}
+}
+
+func TestLenPrefixKind(t *testing.T) {
+	got := struct {
+		Data []byte `flatfile:"1,2,,lenPrefix"`
+	}{}
+	if err := Unmarshal([]byte("03ABCXX"), &got, 0, 0, false); err != nil || string(got.Data) != "ABC" {
+		t.Errorf("Unmarshal lenPrefix []byte got: %q err: %v want: ABC", got.Data, err)
+	}
+
+	//lenPrefix on any other kind is a tag error rather than taking the rest of the record
+	for _, v := range []interface{}{
+		&struct {
+			Amount int `flatfile:"1,2,,lenPrefix"`
+		}{},
+		&struct {
+			Codes []string `flatfile:"1,2,3,lenPrefix"`
+		}{},
+	} {
+		if err := ValidateSchema(v); err == nil || !strings.Contains(err.Error(), "lenPrefix can only be used with a string or []byte field") {
+			t.Errorf("ValidateSchema(%T) err: %v want message containing: lenPrefix can only be used with a string or []byte field", v, err)
+		}
+		if err := Unmarshal([]byte("03ABCXX"), v, 0, 0, false); err == nil {
+			t.Errorf("Unmarshal(%T) should return an error", v)
+		}
+	}
+}
+
+func TestCalcNumFieldsToUnmarshalLenPrefix(t *testing.T) {
+	type FfpTest struct {
+		Name string `flatfile:"1,3,,lenPrefix"`
+		Code string `flatfile:"9,2"`
+	}
+
+	var tests = []struct {
+		Record []byte
+		Want   int
+	}{
+		{[]byte("005HELLO"), 1},
+		{[]byte("005HELLOAB"), 2},
+		{[]byte("00"), 0},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("CalcNumFieldsToUnmarshalLenPrefix-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got, _, err := CalcNumFieldsToUnmarshal(tt.Record, &FfpTest{}, 0)
+			if err != nil {
+				t.Errorf("err: %s", err)
+			}
+			if got != tt.Want {
+				t.Errorf("CalcNumFieldsToUnmarshal(%s,0) got: %d want: %d", string(tt.Record), got, tt.Want)
+			}
+		})
+	}
+}
+
+func TestRepeatingElementErr_Unmarshal(t *testing.T) {
+	type Item struct {
+		Code string `flatfile:"1,1"`
+		Qty  int    `flatfile:"2,2"`
+	}
+	type FfpTest struct {
+		ID    string  `flatfile:"1,2"`
+		Items []Item  `flatfile:"3,3,3"`
+		Sizes [3]uint `flatfile:"12,1"`
+	}
+
+	var tests = []struct {
+		Record  []byte
+		WantMsg string
+	}{
+		{[]byte("IDA01B02CXX123"), "element 2 at col 9"},
+		{[]byte("IDA01B02C0312X"), "element 2 at col 14"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestRepeatingElementErr_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			err := Unmarshal(tt.Record, &FfpTest{}, 0, 0, false)
+			if err == nil {
+				t.Fatalf("Unmarshal(%s) should return an error", string(tt.Record))
+			}
+			if !strings.Contains(err.Error(), tt.WantMsg) {
+				t.Errorf("Unmarshal(%s) got: %s want message containing: %s", string(tt.Record), err, tt.WantMsg)
+			}
+		})
+	}
+}
+
+func TestUnmarshalFields(t *testing.T) {
+	type Profile struct {
+		Name    string `flatfile:"1,3"`
+		Age     int    `flatfile:"4,2"`
+		Country string `flatfile:"6,2"`
+		Note    string
+	}
+
+	var tests = []struct {
+		Fields  []string
+		Want    Profile
+		isError bool
+	}{
+		{[]string{"Country"}, Profile{Country: "CA"}, false},
+		{[]string{"Country", "Name"}, Profile{Name: "AMY", Country: "CA"}, false},
+		{[]string{}, Profile{}, false},
+		{[]string{"Missing"}, Profile{}, true},
+		{[]string{"Note"}, Profile{}, true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestUnmarshalFields-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := Profile{}
+			err := UnmarshalFields([]byte("AMY19CA"), &got, tt.Fields...)
+			if (err != nil) != tt.isError {
+				t.Errorf("UnmarshalFields(%v) err: %v want error: %v", tt.Fields, err, tt.isError)
+			}
+			if got != tt.Want {
+				t.Errorf("UnmarshalFields(%v) got: %v want: %v", tt.Fields, got, tt.Want)
+			}
+		})
+	}
+
+	for _, v := range []interface{}{nil, Profile{}, (*Profile)(nil)} {
+		if err := UnmarshalFields([]byte("AMY19CA"), v, "Name"); err == nil {
+			t.Errorf("UnmarshalFields(%#v) should return an error", v)
+		}
+	}
+}
+
+func TestNullSentinel_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Amount      *int   `flatfile:"1,6,null=999999"`
+		Date        string `flatfile:"7,10,null=9999-12-31"`
+		DateIsNull  bool
+		Blank       *string `flatfile:"17,3,null="`
+		Count       int     `flatfile:"20,2,,null=00"`
+		CountIsNull bool
+	}
+
+	testVal := &FfpTest{Date: "stale", Count: 5}
+	err := Unmarshal([]byte("9999999999-12-31   00"), testVal, 0, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FfpTest{DateIsNull: true, CountIsNull: true}
+	if !reflect.DeepEqual(*testVal, want) {
+		t.Errorf("Unmarshal null sentinels got: %+v want: %+v", *testVal, want)
+	}
+
+	err = Unmarshal([]byte("0001232020-01-01ABC07"), testVal, 0, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if testVal.Amount == nil || *testVal.Amount != 123 || testVal.Date != "2020-01-01" || testVal.DateIsNull ||
+		testVal.Blank == nil || *testVal.Blank != "ABC" || testVal.Count != 7 || testVal.CountIsNull {
+		t.Errorf("Unmarshal non null values got: %+v", *testVal)
+	}
+}
+
+func TestConst_Unmarshal(t *testing.T) {
+	type Header struct {
+		Type    string `flatfile:"1,3,const=HDR"`
+		Version int    `flatfile:"4,2,const=02"`
+		Date    string `flatfile:"6,8"`
+	}
+
+	var tests = []struct {
+		Record  string
+		WantMsg string
+	}{
+		{"HDR0220200101", ""},
+		{"TRL0220200101", "field Type col 1 len 3 value \"TRL\": flatfile.Unmarshal: Expected constant \"HDR\" but got \"TRL\""},
+		{"HDR0320200101", "field Version col 4 len 2 value \"03\": flatfile.Unmarshal: Expected constant \"02\" but got \"03\""},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestConst_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			testVal := &Header{}
+			err := Unmarshal([]byte(tt.Record), testVal, 0, 0, false)
+			if tt.WantMsg == "" {
+				if err != nil || testVal.Type != "HDR" || testVal.Version != 2 {
+					t.Errorf("Unmarshal(%s,0,0,false) got: %+v err: %v", tt.Record, testVal, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.WantMsg) {
+				t.Errorf("Unmarshal(%s,0,0,false) err: %v want message containing: %s", tt.Record, err, tt.WantMsg)
+			}
+		})
+	}
+}
+
+func TestKeepRaw_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Account string `flatfile:"1,6,,keepraw"`
+		Name    string `flatfile:"7,4"`
+	}
+
+	testVal := &FfpTest{}
+	err := Unmarshal([]byte("0012\xe9 Jos\xe9"), testVal, 0, 0, false, WithEncoding(Latin1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if testVal.Account != "0012\xe9 " || testVal.Name != "José" {
+		t.Errorf("Unmarshal keepraw got: %q", *testVal)
+	}
+
+	if err := parseFlatfileTag("1,6,keepraw,transform=upper", &flatfileTag{}); err == nil {
+		t.Error("parseFlatfileTag should return an error when keepraw and transform are used together")
+	}
+}
+
+func TestIgnoreTag_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Name   string `flatfile:"1,3"`
+		Loaded bool   `flatfile:"-"`
+		Age    int    `flatfile:"4,2"`
+	}
+
+	testVal := &FfpTest{Loaded: true}
+	err := Unmarshal([]byte("AMY30"), testVal, 0, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *testVal != (FfpTest{Name: "AMY", Loaded: true, Age: 30}) {
+		t.Errorf("Unmarshal with an ignored field got: %+v", *testVal)
+	}
+	numFields, _, err := CalcNumFieldsToUnmarshal([]byte("AMY30"), testVal, 0)
+	if err != nil || numFields != 2 {
+		t.Errorf("CalcNumFieldsToUnmarshal with an ignored field got: %d err: %v", numFields, err)
+	}
+}
+
+func TestSignField_Unmarshal(t *testing.T) {
+	type Ledger struct {
+		Sign    string  `flatfile:"1,1"`
+		Amount  int     `flatfile:"2,4,signField=Sign"`
+		Balance float64 `flatfile:"6,5,signField=DrCr"`
+		DrCr    string  `flatfile:"11,1"`
+	}
+
+	var tests = []struct {
+		Record  string
+		Want    Ledger
+		WantErr bool
+	}{
+		{"-012310.50D", Ledger{"-", -123, -10.5, "D"}, false},
+		{"+012310.50C", Ledger{"+", 123, 10.5, "C"}, false},
+		{" 012310.50 ", Ledger{" ", 123, 10.5, " "}, false},
+		{"X012310.50C", Ledger{}, true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestSignField_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			testVal := &Ledger{}
+			err := Unmarshal([]byte(tt.Record), testVal, 0, 0, false)
+			if (err != nil) != tt.WantErr {
+				t.Fatalf("Unmarshal(%s,0,0,false) err: %v want err: %v", tt.Record, err, tt.WantErr)
+			}
+			if !tt.WantErr && *testVal != tt.Want {
+				t.Errorf("Unmarshal(%s,0,0,false) got: %+v want: %+v", tt.Record, *testVal, tt.Want)
+			}
+		})
+	}
+
+	type BadSign struct {
+		Amount int `flatfile:"1,4,signField=Missing"`
+	}
+	if err := Unmarshal([]byte("0123"), &BadSign{}, 0, 0, false); err == nil || !strings.Contains(err.Error(), "has no field Missing") {
+		t.Errorf("Unmarshal with a missing sign field err: %v", err)
+	}
+}
+
+func TestPercent_Unmarshal(t *testing.T) {
+	type Rates struct {
+		Rate     float64 `flatfile:"1,5,percent=3"`
+		Fraction float64 `flatfile:"1,5,percent=3,fraction"`
+		Rate32   float32 `flatfile:"1,5,percent=2"`
+	}
+
+	var tests = []struct {
+		Record  string
+		Want    Rates
+		WantErr bool
+	}{
+		{"02550", Rates{2.55, 0.0255, 25.5}, false},
+		{"100.0", Rates{100, 1, 100}, false},
+		{"0000 ", Rates{}, false},
+		{"2.5x0", Rates{}, true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestPercent_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			testVal := &Rates{}
+			err := Unmarshal([]byte(tt.Record), testVal, 0, 0, false)
+			if (err != nil) != tt.WantErr {
+				t.Fatalf("Unmarshal(%s,0,0,false) err: %v want err: %v", tt.Record, err, tt.WantErr)
+			}
+			if !tt.WantErr && *testVal != tt.Want {
+				t.Errorf("Unmarshal(%s,0,0,false) got: %+v want: %+v", tt.Record, *testVal, tt.Want)
+			}
+		})
+	}
+
+	if err := parseFlatfileTag("1,5,fraction", &flatfileTag{}); err == nil {
+		t.Error("parseFlatfileTag should return an error for fraction without percent")
+	}
+}
+
+func TestMalformedInputErr_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Name  string `flatfile:"1,3"`
+		Codes [2]int `flatfile:"4,1"`
+		Tail  string `flatfile:"6,3,cond=9-2-XX"`
+	}
+	type AllStrings struct {
+		Name string `flatfile:"1,3"`
+		City string `flatfile:"4,5"`
+	}
+	type Unexported struct {
+		name string `flatfile:"1,3"`
+	}
+	var nilPtr *FfpTest
+
+	var tests = []struct {
+		Data     string
+		V        interface{}
+		StartIdx int
+		WantMsg  string
+	}{
+		{"AMY1", &FfpTest{}, 0, "Record of length 4 ends before the field ends at col 5"},
+		{"AMYTOR", &AllStrings{}, 0, "Record of length 6 ends before the field ends at col 8"},
+		{"AMY", &Unexported{}, 0, "Field name is unexported and cannot be set"},
+		{"AMY", nilPtr, 0, "is nil"},
+		{"AMY", nil, 0, "is not a pointer"},
+		{"AMY", &FfpTest{}, -1, "startFieldIdx -1 is not a field index"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestMalformedInputErr_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			err := Unmarshal([]byte(tt.Data), tt.V, tt.StartIdx, 0, false)
+			if err == nil || !strings.Contains(err.Error(), tt.WantMsg) {
+				t.Errorf("Unmarshal(%s) err: %v want message containing: %s", tt.Data, err, tt.WantMsg)
+			}
+		})
+	}
+
+	//a condition past the end of the record does not match
+	testVal := &FfpTest{}
+	if err := Unmarshal([]byte("AMY12"), testVal, 0, 0, false); err != nil || testVal.Tail != "" {
+		t.Errorf("Unmarshal with a condition outside of the record got: %+v err: %v", testVal, err)
+	}
+}
+
+func TestTerm_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Name  string   `flatfile:"1,8,term=0x00"`
+		Count int      `flatfile:"9,4,term=|"`
+		Codes []string `flatfile:"13,3,2,term=0x00"`
+		Raw   string   `flatfile:"1,8"`
+	}
+
+	testVal := &FfpTest{}
+	err := Unmarshal([]byte("AMY\x00\xff\x12ZZ42|xA\x00qBC\x00"), testVal, 0, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FfpTest{Name: "AMY", Count: 42, Codes: []string{"A", "BC"}, Raw: "AMY\x00\xff\x12ZZ"}
+	if !reflect.DeepEqual(*testVal, want) {
+		t.Errorf("Unmarshal term got: %q want: %q", *testVal, want)
+	}
+
+	for _, tag := range []string{"1,8,term=0x0", "1,8,term=0xzz", "1,8,term=ab"} {
+		if err := parseFlatfileTag(tag, &flatfileTag{}); err == nil {
+			t.Errorf("parseFlatfileTag(%s) should return an error", tag)
+		}
+	}
+}
+
+func TestTrim_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Right  string   `flatfile:"1,6,ltrim"`
+		Left   string   `flatfile:"7,6,rtrim"`
+		Both   string   `flatfile:"13,6,trim"`
+		Codes  []string `flatfile:"19,3,2,trim"`
+		Padded string   `flatfile:"13,6"`
+	}
+
+	testVal := &FfpTest{}
+	err := Unmarshal([]byte("  A BCA B   \t A B  X  Y "), testVal, 0, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FfpTest{Right: "A BC", Left: "A B", Both: "A B", Codes: []string{"X", "Y"}, Padded: "\t A B "}
+	if !reflect.DeepEqual(*testVal, want) {
+		t.Errorf("Unmarshal trim got: %q want: %q", *testVal, want)
+	}
+
+	if err := parseFlatfileTag("1,6,keepraw,trim", &flatfileTag{}); err == nil {
+		t.Error("parseFlatfileTag should return an error when keepraw and trim are used together")
+	}
+}
+
+func TestUnmarshalWithRaw(t *testing.T) {
+	type inner struct {
+		Code string `flatfile:"1,2"`
+	}
+	type record struct {
+		Account string `flatfile:"1,6"`
+		Amount  int    `flatfile:"7,4"`
+		Inner   inner  `flatfile:"11,2"`
+		Note    string
+	}
+	data := []byte("0012340050XY")
+	var got record
+	raw, err := UnmarshalWithRaw(data, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := record{Account: "001234", Amount: 50, Inner: inner{Code: "XY"}}
+	if got != want {
+		t.Errorf("UnmarshalWithRaw(%s) got: %v want: %v", data, got, want)
+	}
+	wantRaw := map[string][]byte{"Account": []byte("001234"), "Amount": []byte("0050"), "Inner": []byte("XY")}
+	if !reflect.DeepEqual(raw, wantRaw) {
+		t.Errorf("UnmarshalWithRaw(%s) raw got: %q want: %q", data, raw, wantRaw)
+	}
+
+	raw, err = UnmarshalWithRaw([]byte("0012340X50XY"), &got)
+	if err == nil {
+		t.Fatalf("UnmarshalWithRaw should return an error for an invalid amount")
+	}
+	if string(raw["Amount"]) != "0X50" {
+		t.Errorf("UnmarshalWithRaw should return the raw bytes of the field that failed got: %q", raw)
+	}
+}
+
+func TestFieldOrder_Unmarshal(t *testing.T) {
+	type Declared struct {
+		Name string `flatfile:"1,3"`
+		Age  int    `flatfile:"4,2"`
+		Note string `flatfile:"6,10"`
+	}
+	type Reordered struct {
+		Note string `flatfile:"6,10"`
+		Age  int    `flatfile:"4,2"`
+		Name string `flatfile:"1,3"`
+	}
+
+	data := []byte("AMY30HI")
+	declared := &Declared{}
+	if err := Unmarshal(data, declared, 0, 0, false, WithPartialLastField()); err != nil {
+		t.Fatal(err)
+	}
+	reordered := &Reordered{}
+	if err := Unmarshal(data, reordered, 0, 0, false, WithPartialLastField()); err != nil {
+		t.Fatal(err)
+	}
+	if declared.Name != reordered.Name || declared.Age != reordered.Age || declared.Note != reordered.Note || reordered.Note != "HI" {
+		t.Errorf("Unmarshal should not depend on field order got: %+v and %+v", *declared, *reordered)
+	}
+
+	//a gap between fields is left in the remainder rather than shifting the fields after it
+	type Gapped struct {
+		Code string `flatfile:"1,2"`
+		Qty  int    `flatfile:"5,2"`
+	}
+	numFields, remainder, err := CalcNumFieldsToUnmarshal([]byte("AB  12XY"), &Gapped{}, 0)
+	if err != nil || numFields != 2 || string(remainder) != "XY" {
+		t.Errorf("CalcNumFieldsToUnmarshal with a gap got: %d %q err: %v", numFields, remainder, err)
+	}
+}
+
+func TestTrimSet_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Amount  int     `flatfile:"1,8,trimset=*"`
+		Masked  int     `flatfile:"9,4,trimset=*,masked"`
+		Rate    float64 `flatfile:"13,6,trimset=*"`
+		Account string  `flatfile:"19,6,trimset=*#"`
+	}
+
+	var tests = []struct {
+		Record string
+		Want   FfpTest
+	}{
+		{"****1234**12**1.25##AB##", FfpTest{Amount: 1234, Masked: 12, Rate: 1.25, Account: "AB"}},
+		{"00001234******1.25  AB  ", FfpTest{Amount: 1234, Masked: 0, Rate: 1.25, Account: "  AB  "}},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestTrimSet_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := FfpTest{Masked: 99}
+			if err := Unmarshal([]byte(tt.Record), &got, 0, 0, false); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.Want {
+				t.Errorf("Unmarshal(%s) got: %+v want: %+v", tt.Record, got, tt.Want)
+			}
+		})
+	}
+
+	//without masked an all asterisk field has no digits to parse
+	if err := Unmarshal([]byte("********"), &FfpTest{}, 0, 1, false); err == nil {
+		t.Error("Unmarshal should return an error for a masked field without the masked flag")
+	}
+	if err := parseFlatfileTag("1,4,masked", &flatfileTag{}); err == nil {
+		t.Error("parseFlatfileTag should return an error when masked is used without trimset")
+	}
+}
+
+func TestBitFlags_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Status uint8   `flatfile:"1,1,,bitflags"`
+		Wide   uint16  `flatfile:"2,2,,bitflags"`
+		Flags  []uint8 `flatfile:"4,1,2,bitflags"`
+		Opt    *uint32 `flatfile:"6,1,,bitflags"`
+	}
+
+	testVal := &FfpTest{}
+	err := Unmarshal([]byte{0x05, 0x01, 0x80, 'A', 0xFF, 0x00}, testVal, 0, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if testVal.Status != 0x05 || testVal.Status&0x04 == 0 || testVal.Wide != 0x0180 || !reflect.DeepEqual(testVal.Flags, []uint8{'A', 0xFF}) || *testVal.Opt != 0 {
+		t.Errorf("Unmarshal bitflags got: %+v", *testVal)
+	}
+
+	tooWide := &struct {
+		Status uint8 `flatfile:"1,2,,bitflags"`
+	}{}
+	if err := Unmarshal([]byte{0x01, 0x02}, tooWide, 0, 0, false); err == nil {
+		t.Error("Unmarshal should return an error when bitflags bytes do not fit the field")
+	}
+	signed := &struct {
+		Status int8 `flatfile:"1,1,,bitflags"`
+	}{}
+	if err := Unmarshal([]byte{0x01}, signed, 0, 0, false); err == nil {
+		t.Error("Unmarshal should return an error for bitflags on a signed field")
+	}
+}
+
+func TestZoned_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Credit  int64  `flatfile:"1,3,,zoned"`
+		Debit   int32  `flatfile:"4,3,,zoned"`
+		Count   uint16 `flatfile:"7,2,,zoned"`
+		Name    string `flatfile:"9,2"`
+		Amounts []int  `flatfile:"11,2,2,zoned"`
+		Opt     *int   `flatfile:"15,2,,zoned"`
+	}
+
+	//zoned digits are read from the raw bytes so the encoding only applies to Name
+	data := []byte{0xF1, 0xF2, 0xC3, 0xF1, 0xF2, 0xD3, 0xF4, 0xF5, 0xE9, 0x41, 0xF0, 0xF1, 0xF0, 0xD9, 0xF0, 0xF7}
+	testVal := &FfpTest{}
+	if err := Unmarshal(data, testVal, 0, 0, false, WithEncoding(Latin1)); err != nil {
+		t.Fatal(err)
+	}
+	want := FfpTest{Credit: 123, Debit: -123, Count: 45, Name: "éA", Amounts: []int{1, -9}}
+	opt := 7
+	want.Opt = &opt
+	if !reflect.DeepEqual(*testVal, want) {
+		t.Errorf("Unmarshal zoned got: %+v want: %+v", *testVal, want)
+	}
+
+	errTests := []struct {
+		data []byte
+		want string
+	}{
+		{[]byte{0xF1, 0xC2, 0xF3}, "Invalid zone 0xC2 at byte 2"},
+		{[]byte{0xF1, 0xFA, 0xF3}, "Invalid digit 0xFA at byte 2"},
+		{[]byte("123"), "Invalid zone 0x31 at byte 1"},
+	}
+	for idx, tt := range errTests {
+		t.Run(fmt.Sprintf("TestZoned_Unmarshal-%d", idx), func(t *testing.T) {
+			err := Unmarshal(tt.data, &struct {
+				Amount int `flatfile:"1,3,,zoned"`
+			}{}, 0, 0, false)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Unmarshal zoned err: %v want message containing: %s", err, tt.want)
+			}
+		})
+	}
+	unsigned := &struct {
+		Count uint8 `flatfile:"1,2,,zoned"`
+	}{}
+	if err := Unmarshal([]byte{0xF1, 0xD2}, unsigned, 0, 0, false); err == nil {
+		t.Error("Unmarshal should return an error for a negative zoned value in an unsigned field")
+	}
+}
+
+func TestPacked_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Credit  int64   `flatfile:"1,3,packed"`
+		Debit   int32   `flatfile:"4,2,,packed"`
+		Balance float64 `flatfile:"6,3,packed,scale=2"`
+		Total   Cents   `flatfile:"9,3,packed,scale=2"`
+		Name    string  `flatfile:"12,2"`
+		Counts  []uint8 `flatfile:"14,1,2,packed"`
+		Opt     *int    `flatfile:"16,2,,packed"`
+	}
+
+	//packed digits are read from the raw bytes so the encoding only applies to Name
+	data := []byte{0x01, 0x23, 0x4C, 0x12, 0x3D, 0x12, 0x34, 0x5D, 0x00, 0x12, 0x3F, 0xC8, 0xC9, 0x1C, 0x9F, 0x00, 0x7C}
+	testVal := &FfpTest{}
+	if err := Unmarshal(data, testVal, 0, 0, false, WithEncoding(CP037)); err != nil {
+		t.Fatal(err)
+	}
+	opt := 7
+	want := FfpTest{Credit: 1234, Debit: -123, Balance: -123.45, Total: 123, Name: "HI", Counts: []uint8{1, 9}, Opt: &opt}
+	if !reflect.DeepEqual(*testVal, want) {
+		t.Errorf("Unmarshal packed got: %+v want: %+v", *testVal, want)
+	}
+
+	errTests := []struct {
+		data []byte
+		v    interface{}
+		want string
+	}{
+		{[]byte{0x12, 0x34}, &struct {
+			Amount int `flatfile:"1,2,packed"`
+		}{}, "Invalid sign nibble in 0x34 at byte 2"},
+		{[]byte{0x1A, 0x3C}, &struct {
+			Amount int `flatfile:"1,2,packed"`
+		}{}, "Invalid digit in 0x1A at byte 1"},
+		{[]byte{0x12, 0x3C}, &struct {
+			Amount int `flatfile:"1,2,packed,scale=1"`
+		}{}, "invalid syntax"},
+		{[]byte{0x12, 0x3C}, &struct {
+			Amount float64 `flatfile:"1,2,packed,scale=4"`
+		}{}, "packed implied decimals 4 cannot exceed the 3 digits of the field"},
+		{[]byte{0x12, 0x3C}, &struct {
+			Amount float64 `flatfile:"1,2,scale=1"`
+		}{}, "scale can only be used with the packed option"},
+		{[]byte{0x12, 0x3C}, &struct {
+			Amount float64 `flatfile:"1,2,packed=1"`
+		}{}, "Invalid tag parameter packed"},
+	}
+	for idx, tt := range errTests {
+		t.Run(fmt.Sprintf("TestPacked_Unmarshal-%d", idx), func(t *testing.T) {
+			err := Unmarshal(tt.data, tt.v, 0, 0, false)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Unmarshal packed err: %v want message containing: %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunLength_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Bar   int     `flatfile:"1,10,,runlen=#"`
+		Stars uint8   `flatfile:"11,5,,runlen=*"`
+		Level float64 `flatfile:"16,4,runlen=|"`
+		Opt   *int    `flatfile:"20,3,,runlen=#"`
+	}
+
+	var tests = []struct {
+		Record string
+		Want   [4]float64
+	}{
+		{"######    ***  ||||#  ", [4]float64{6, 3, 4, 1}},
+		{"##########*****    ###", [4]float64{10, 5, 0, 3}},
+		{"          -****||  #  ", [4]float64{0, 0, 2, 1}},
+		{"###-###   ** **| |    ", [4]float64{3, 2, 1, 0}},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestRunLength_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			testVal := &FfpTest{}
+			if err := Unmarshal([]byte(tt.Record), testVal, 0, 0, false); err != nil {
+				t.Fatal(err)
+			}
+			if testVal.Opt == nil {
+				t.Fatalf("Unmarshal(%s,0,0,false) left Opt nil", tt.Record)
+			}
+			got := [4]float64{float64(testVal.Bar), float64(testVal.Stars), testVal.Level, float64(*testVal.Opt)}
+			if got != tt.Want {
+				t.Errorf("Unmarshal(%s,0,0,false) got: %v want: %v", tt.Record, got, tt.Want)
+			}
+		})
+	}
+
+	overflow := &struct {
+		Count int8 `flatfile:"1,200,,runlen=#"`
+	}{}
+	if err := Unmarshal(bytes.Repeat([]byte("#"), 200), overflow, 0, 0, false); err == nil || !strings.Contains(err.Error(), "200 overflows int8") {
+		t.Errorf("Unmarshal runlen err: %v want message containing: 200 overflows int8", err)
+	}
+}
+
+func TestCallbackField_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Name  string             `flatfile:"1,5"`
+		Notes func([]byte) error `flatfile:"6,5"`
+		Check func([]byte) error `flatfile:"11,2"`
+	}
+
+	var notes bytes.Buffer
+	testVal := &FfpTest{
+		Notes: func(data []byte) error {
+			_, err := notes.Write(data)
+			return err
+		},
+		Check: func(data []byte) error {
+			if string(data) != "OK" {
+				return fmt.Errorf("check failed: %s", data)
+			}
+			return nil
+		},
+	}
+	if err := Unmarshal([]byte("HelloWorldOK"), testVal, 0, 0, false, WithZeroFirst()); err != nil {
+		t.Fatal(err)
+	}
+	if testVal.Name != "Hello" || notes.String() != "World" {
+		t.Errorf("Unmarshal callback got name: %s notes: %s", testVal.Name, notes.String())
+	}
+
+	err := Unmarshal([]byte("HelloWorldNO"), testVal, 0, 0, false)
+	if fieldErr, ok := err.(*FieldError); !ok || fieldErr.Field != "Check" || !strings.Contains(err.Error(), "check failed: NO") {
+		t.Errorf("Unmarshal should return the error of a callback for field Check got: %v", err)
+	}
+	if err := Unmarshal([]byte("HelloWorldOK"), &FfpTest{}, 0, 0, false); err == nil || !strings.Contains(err.Error(), "Callback field is nil") {
+		t.Errorf("Unmarshal should return an error for a nil callback got: %v", err)
+	}
+}
+
+func TestStringSlice_Unmarshal(t *testing.T) {
+	type Code string
+	type FfpTest struct {
+		Raw     []string  `flatfile:"1,10,5"`
+		Trimmed []string  `flatfile:"1,10,5,trim"`
+		Codes   []Code    `flatfile:"1,10,5,rtrim,transform=lower"`
+		Places  [2]string `flatfile:"51,6,,rtrim"`
+	}
+
+	data := []byte("ALPHA     BRAVO     CHARLIE   DELTA       ECHO    Z\xfcrichWien  ")
+	testVal := &FfpTest{}
+	if err := Unmarshal(data, testVal, 0, 0, false, WithEncoding(Latin1)); err != nil {
+		t.Fatal(err)
+	}
+	want := FfpTest{
+		Raw:     []string{"ALPHA     ", "BRAVO     ", "CHARLIE   ", "DELTA     ", "  ECHO    "},
+		Trimmed: []string{"ALPHA", "BRAVO", "CHARLIE", "DELTA", "ECHO"},
+		Codes:   []Code{"alpha", "bravo", "charlie", "delta", "  echo"},
+		Places:  [2]string{"Zürich", "Wien"},
+	}
+	if !reflect.DeepEqual(*testVal, want) {
+		t.Errorf("Unmarshal string slice got: %q want: %q", *testVal, want)
+	}
+}
+
+func TestScalarOccursErr_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Name string `flatfile:"1,5,3"`
+		Age  int    `flatfile:"16,2"`
+	}
+
+	data := []byte("AMY  BOB  CAT  30")
+	if err := Unmarshal(data, &FfpTest{}, 0, 0, false); err == nil || !strings.Contains(err.Error(), "Occurs can only be used with a slice or array field") {
+		t.Errorf("Unmarshal with occurs on a string field err: %v", err)
+	}
+	if _, _, err := CalcNumFieldsToUnmarshal(data, &FfpTest{}, 0); err == nil {
+		t.Error("CalcNumFieldsToUnmarshal should return an error for occurs on a string field")
+	}
+}
+
+func TestParen_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Count int64 `flatfile:"1,6,,paren"`
+	}
+
+	var tests = []struct {
+		Record  string
+		Want    int64
+		WantErr bool
+	}{
+		{"(123)", -123, false},
+		{" (45) ", -45, false},
+		{"  123 ", 123, false},
+		{"()    ", 0, false},
+		{"( )   ", 0, false},
+		{"(123  ", 0, true},
+		{"(-123)", 0, true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestParen_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := FfpTest{}
+			err := Unmarshal([]byte(tt.Record), &got, 0, 0, false, WithPartialLastField())
+			if (err != nil) != tt.WantErr {
+				t.Fatalf("Unmarshal(%s) err: %v want err: %v", tt.Record, err, tt.WantErr)
+			}
+			if got.Count != tt.Want {
+				t.Errorf("Unmarshal(%s) got: %d want: %d", tt.Record, got.Count, tt.Want)
+			}
+		})
+	}
+}
+
+func TestAutoColumn_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Name   string `flatfile:"auto,3"`
+		Age    int    `flatfile:"auto,2"`
+		Scores [2]int `flatfile:"auto,2"`
+		Code   string `flatfile:"12,2"`
+		Rest   string `flatfile:"auto,3"`
+	}
+
+	testVal := &FfpTest{}
+	err := Unmarshal([]byte("AMY301020XXCAEND"), testVal, 0, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FfpTest{Name: "AMY", Age: 30, Scores: [2]int{10, 20}, Code: "CA", Rest: "END"}
+	if *testVal != want {
+		t.Errorf("Unmarshal auto columns got: %+v want: %+v", *testVal, want)
+	}
+
+	afterGreedy := &struct {
+		Names []string `flatfile:"1,3,-1"`
+		Code  string   `flatfile:"auto,2"`
+	}{}
+	if err := Unmarshal([]byte("AMYBOB"), afterGreedy, 0, 0, false); err == nil || !strings.Contains(err.Error(), "previous tagged field has a variable length") {
+		t.Errorf("Unmarshal auto column after a greedy field err: %v", err)
+	}
+}
+
+func TestOverlappingFields_Unmarshal(t *testing.T) {
+	type Profile struct {
+		FirstName string `flatfile:"1,10"`
+		LastName  string `flatfile:"11,10"`
+		FullName  string `flatfile:"1,20"`
+		Initials  string `flatfile:"1,1"`
+	}
+	data := []byte("AMY       SMITH     ")
+	want := Profile{FirstName: "AMY       ", LastName: "SMITH     ", FullName: "AMY       SMITH     ", Initials: "A"}
+
+	got := Profile{}
+	if err := Unmarshal(data, &got, 0, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Unmarshal overlapping fields got: %+v want: %+v", got, want)
+	}
+
+	//read the record in chunks as the buffered reader example does
+	got = Profile{}
+	buffer := []byte{}
+	fieldIdx := 0
+	for offset := 0; offset < len(data); offset += 7 {
+		buffer = append(buffer, data[offset:min(offset+7, len(data))]...)
+		numFields, remainder, err := CalcNumFieldsToUnmarshal(buffer, &got, fieldIdx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if numFields > 0 {
+			if err := Unmarshal(buffer, &got, fieldIdx, numFields, true); err != nil {
+				t.Fatal(err)
+			}
+			fieldIdx += numFields
+			buffer = append([]byte{}, remainder...)
+		}
+	}
+	if got != want || fieldIdx != 4 {
+		t.Errorf("Unmarshal overlapping fields in chunks got: %+v after %d fields want: %+v", got, fieldIdx, want)
+	}
+}
+
+func TestRegex_Unmarshal(t *testing.T) {
+	type FfpTest struct {
+		Ref  int    `flatfile:"1,12,,regex=REF-(\\d+)"`
+		Code string `flatfile:"13,8,regex=[A-Z]{2}\\d"`
+		Opt  int    `flatfile:"21,6,regex=#(\\d+),nomatch=zero"`
+	}
+
+	var tests = []struct {
+		Record  string
+		Want    FfpTest
+		WantErr bool
+	}{
+		{"  REF-00123 xxAB1yyy  #42 ", FfpTest{Ref: 123, Code: "AB1", Opt: 42}, false},
+		{"REF-7       CD9       none  ", FfpTest{Ref: 7, Code: "CD9"}, false},
+		{"ABC-00123   CD9       #1    ", FfpTest{}, true},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestRegex_Unmarshal-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			got := FfpTest{Opt: 99}
+			err := Unmarshal([]byte(tt.Record), &got, 0, 0, false)
+			if (err != nil) != tt.WantErr {
+				t.Fatalf("Unmarshal(%s) err: %v want err: %v", tt.Record, err, tt.WantErr)
+			}
+			if !tt.WantErr && got != tt.Want {
+				t.Errorf("Unmarshal(%s) got: %+v want: %+v", tt.Record, got, tt.Want)
+			}
+		})
+	}
+
+	if err := ValidateSchema(&struct {
+		Ref int `flatfile:"1,12,regex=REF-(\\d+"`
+	}{}); err == nil {
+		t.Error("ValidateSchema should return an error for an invalid regex")
+	}
+}
@@ -0,0 +1,196 @@
+package flatfile
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//ValidateSchema checks the flatfile tags of struct v, a struct or pointer to a struct, without unmarshalling any data
+//Nested structs are checked too. Call it at startup to catch layout mistakes before the first record is read
+//The following are reported:
+//	Tags that cannot be parsed
+//	Slice fields without an occurs, other than []rune fields using the rune override
+//	Slices of slices without an occurs in the form rows x columns
+//	An occurs on a field that is not a slice or array
+//	Nested structs that contain their own type, directly or through a pointer
+//opts: optional checks e.g. WithRequireTags()
+func ValidateSchema(v interface{}, opts ...ValidateOption) error {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return errors.Errorf("flatfile.ValidateSchema: Expected a struct or pointer to a struct but got %v", reflect.TypeOf(v))
+	}
+	o := &validateOptions{visiting: make(map[reflect.Type]string)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return validateStruct(t, "", o)
+}
+
+//ValidateOption configures optional checks of ValidateSchema
+type ValidateOption func(*validateOptions)
+
+type validateOptions struct {
+	requireTags  bool
+	sequential   bool
+	recordLength int
+	//visiting maps each struct type being checked to its field path, to stop at a type that contains itself
+	visiting map[reflect.Type]string
+}
+
+//WithRequireTags reports exported fields without a flatfile tag, catching fields added to a record struct but never positioned
+//Fields that are intentionally not part of the record can be marked with `flatfile:"-"`. Unexported fields are exempt
+func WithRequireTags() ValidateOption {
+	return func(o *validateOptions) {
+		o.requireTags = true
+	}
+}
+
+//WithSequential reports a tagged field that starts before the previous tagged field ends, in declaration order
+//It suits simple sequential layouts where a copied column would otherwise silently misalign the fields after it
+//Gaps between fields are allowed. A field after one of variable length is not checked. Schemas of overlapping views should not use it
+func WithSequential() ValidateOption {
+	return func(o *validateOptions) {
+		o.sequential = true
+	}
+}
+
+//WithRecordLength reports a field that ends after column n, the declared length of the record
+//The full width of repeating fields is checked, len times occurs, catching a repeating group that would overrun the record
+//Fields of variable length, such as a greedy occurs or occursAt, are not checked. Nested struct fields are checked as a whole
+func WithRecordLength(n int) ValidateOption {
+	return func(o *validateOptions) {
+		o.recordLength = n
+	}
+}
+
+//validateStruct checks every tagged field of struct type t, prefixing field names with path
+func validateStruct(t reflect.Type, path string, o *validateOptions) error {
+	o.visiting[t] = path
+	defer delete(o.visiting, t)
+	layout := cachedStructLayout(t)
+	//nextCol is the column after the previous tagged field for WithSequential, or 0 if it has a variable length
+	nextCol, prevName := 0, ""
+	for i := range layout.fields {
+		field := &layout.fields[i]
+		structField := t.Field(i)
+		name := path + structField.Name
+		if !field.tagged {
+			if _, tagFlag := structField.Tag.Lookup("flatfile"); o.requireTags && !tagFlag && structField.PkgPath == "" {
+				return errors.Errorf("flatfile.ValidateSchema: Exported field %s has no flatfile tag. Tag it `flatfile:\"-\"` if it is not part of the record", name)
+			}
+			continue
+		}
+		if field.err != nil {
+			return errors.Wrapf(field.err, "flatfile.ValidateSchema: Field %s has invalid tag %s", name, field.rawTag)
+		}
+		if err := validateFieldKind(structField.Type, &field.tag); err != nil {
+			return errors.Wrapf(err, "flatfile.ValidateSchema: Field %s has invalid tag %s", name, field.rawTag)
+		}
+		if o.sequential {
+			if nextCol > 0 && field.tag.col < nextCol {
+				return errors.Errorf("flatfile.ValidateSchema: Field %s starts at col %d before field %s ends at col %d", name, field.tag.col, prevName, nextCol-1)
+			}
+			nextCol, prevName = nextColumn(structField.Type, field), name
+		}
+		//columns of nested struct fields are relative to the parent field so only the top level is checked against the record
+		if o.recordLength > 0 && path == "" {
+			if endCol := nextColumn(structField.Type, field) - 1; endCol > o.recordLength {
+				return errors.Errorf("flatfile.ValidateSchema: Field %s ends at col %d after the record length of %d", name, endCol, o.recordLength)
+			}
+		}
+
+		fieldType := structField.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.tag.conv == "" && isNestedStruct(fieldType) {
+			if outer, cycle := o.visiting[fieldType]; cycle {
+				owner := "the top level struct"
+				if outer != "" {
+					owner = "field " + strings.TrimSuffix(outer, ".")
+				}
+				return errors.Errorf("flatfile.ValidateSchema: Field %s refers back to %s, the type of %s. A recursive struct has no fixed layout", name, fieldType, owner)
+			}
+			if err := validateStruct(fieldType, name+".", o); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//validateFieldKind checks the parsed tag against the kind of field type t
+//These are the mistakes assignBasedOnKind would otherwise only report once data reaches the field
+func validateFieldKind(t reflect.Type, ffpTag *flatfileTag) error {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if ffpTag.occursCol > 0 && t.Kind() != reflect.Slice {
+		return errors.Errorf("flatfile.validateFieldKind: occursAt can only be used with a slice field not %s", t)
+	}
+	if (t == timeType || t == nullTimeType) && ffpTag.layout == "" && ffpTag.conv == "" {
+		return errors.Errorf("flatfile.validateFieldKind: Layout option must be provided when using %s. `flatfile:\"col,len,layout=20060102\"`", t)
+	}
+	if ffpTag.timeField != "" && t != timeType {
+		return errors.Errorf("flatfile.validateFieldKind: timeField can only be used with a time.Time field not %s", t)
+	}
+	if ffpTag.bitFlags {
+		elem := t
+		if elem.Kind() == reflect.Slice || elem.Kind() == reflect.Array {
+			elem = elem.Elem()
+		}
+		switch elem.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			return errors.Errorf("flatfile.validateFieldKind: bitflags can only be used with an unsigned integer field not %s", t)
+		}
+	}
+	if ffpTag.zoned {
+		elem := t
+		if elem.Kind() == reflect.Slice || elem.Kind() == reflect.Array {
+			elem = elem.Elem()
+		}
+		switch elem.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			return errors.Errorf("flatfile.validateFieldKind: zoned can only be used with an integer field not %s", t)
+		}
+	}
+	if ffpTag.packed {
+		elem := t
+		if elem.Kind() == reflect.Slice || elem.Kind() == reflect.Array {
+			elem = elem.Elem()
+		}
+		if !isNumericKind(elem.Kind()) && !implementsFieldUnmarshaler(elem) {
+			return errors.Errorf("flatfile.validateFieldKind: packed can only be used with a numeric or FieldUnmarshaler field not %s", t)
+		}
+	}
+	if ffpTag.decimalsChk {
+		elem := t
+		if elem.Kind() == reflect.Slice || elem.Kind() == reflect.Array {
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Float32 && elem.Kind() != reflect.Float64 {
+			return errors.Errorf("flatfile.validateFieldKind: decimals can only be used with a float field not %s", t)
+		}
+	}
+	if ffpTag.runLenChk && !isNumericKind(t.Kind()) {
+		return errors.Errorf("flatfile.validateFieldKind: runlen can only be used with a numeric field not %s", t)
+	}
+	if t.Kind() != reflect.Slice || ffpTag.conv != "" || implementsFieldUnmarshaler(t) {
+		return nil
+	}
+	if ffpTag.occurs == 0 && ffpTag.occursCol == 0 && !isRuneSequence(t, ffpTag) {
+		return errors.Errorf("flatfile.validateFieldKind: Occurs clause must be provided when using slice %s. `flatfile:\"col,len,occurs\"`", t)
+	}
+	if t.Elem().Kind() == reflect.Slice && ffpTag.innerOccurs == 0 {
+		return errors.Errorf("flatfile.validateFieldKind: Occurs clause must be in the form rows x columns when using slice of slices %s. `flatfile:\"col,len,3x4\"`", t)
+	}
+	return nil
+}
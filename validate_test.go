@@ -0,0 +1,285 @@
+package flatfile
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testValidInner struct {
+	Codes []string `flatfile:"1,2"`
+}
+
+func TestValidateSchema(t *testing.T) {
+	var tests = []struct {
+		V       interface{}
+		WantMsg string
+	}{
+		{&struct {
+			Name  string   `flatfile:"1,10"`
+			Codes []string `flatfile:"11,2,3"`
+			Table [][]int  `flatfile:"17,1,2x3"`
+			Runes []rune   `flatfile:"23,4,override=rune"`
+			Inner struct {
+				A int `flatfile:"1,1"`
+			} `flatfile:"27,1"`
+			Ignored []string
+		}{}, ""},
+		{struct {
+			Name string `flatfile:"1,10"`
+		}{}, ""},
+		{&struct {
+			Codes []string `flatfile:"11,2"`
+		}{}, "Field Codes has invalid tag 11,2"},
+		{&struct {
+			Table [][]int `flatfile:"17,1,2"`
+		}{}, "must be in the form rows x columns"},
+		{&struct {
+			Name string `flatfile:"1"`
+		}{}, "Field Name has invalid tag 1"},
+		{&struct {
+			Inner *testValidInner `flatfile:"1,2"`
+		}{}, "Field Inner.Codes has invalid tag 1,2"},
+		{&struct {
+			Opened time.Time `flatfile:"1,8"`
+		}{}, "Layout option must be provided when using time.Time"},
+		{&struct {
+			Status int `flatfile:"1,1,,bitflags"`
+		}{}, "bitflags can only be used with an unsigned integer field"},
+		{&struct {
+			Opened string `flatfile:"1,8,timeField=Clock"`
+			Clock  string `flatfile:"9,4,layout=1504"`
+		}{}, "timeField can only be used with a time.Time field not string"},
+		{&struct {
+			Amount string `flatfile:"1,6,,zoned"`
+		}{}, "zoned can only be used with an integer field not string"},
+		{&struct {
+			Amount int `flatfile:"1,6,decimals=2"`
+		}{}, "decimals can only be used with a float field not int"},
+		{&struct {
+			Amount string `flatfile:"1,6,,packed"`
+		}{}, "packed can only be used with a numeric or FieldUnmarshaler field not string"},
+		{&struct {
+			Bar string `flatfile:"1,10,,runlen=#"`
+		}{}, "runlen can only be used with a numeric field not string"},
+		{&struct {
+			Bar int `flatfile:"1,10,,runlen=##"`
+		}{}, "Fill ## must be a single character"},
+		{&struct {
+			Name string `flatfile:"1,5,3"`
+		}{}, "Occurs can only be used with a slice or array field not string"},
+		{"not a struct", "Expected a struct or pointer to a struct but got string"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestValidateSchema-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			err := ValidateSchema(tt.V)
+			if tt.WantMsg == "" && err != nil {
+				t.Errorf("ValidateSchema(%T) unexpected err: %v", tt.V, err)
+			}
+			if tt.WantMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.WantMsg)) {
+				t.Errorf("ValidateSchema(%T) err: %v want message containing: %s", tt.V, err, tt.WantMsg)
+			}
+		})
+	}
+}
+
+func TestValidateSchemaRequireTags(t *testing.T) {
+	type Inner struct {
+		Code    string `flatfile:"1,2"`
+		Comment string
+	}
+	var tests = []struct {
+		V       interface{}
+		WantMsg string
+	}{
+		{&struct {
+			Name    string `flatfile:"1,10"`
+			Loaded  bool   `flatfile:"-"`
+			private int
+		}{}, ""},
+		{&struct {
+			Name   string `flatfile:"1,10"`
+			Amount int
+		}{}, "Exported field Amount has no flatfile tag"},
+		{&struct {
+			Inner Inner `flatfile:"1,2"`
+		}{}, "Exported field Inner.Comment has no flatfile tag"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestValidateSchemaRequireTags-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			if err := ValidateSchema(tt.V); err != nil {
+				t.Errorf("ValidateSchema(%T) without WithRequireTags unexpected err: %v", tt.V, err)
+			}
+			err := ValidateSchema(tt.V, WithRequireTags())
+			if tt.WantMsg == "" && err != nil {
+				t.Errorf("ValidateSchema(%T) unexpected err: %v", tt.V, err)
+			}
+			if tt.WantMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.WantMsg)) {
+				t.Errorf("ValidateSchema(%T) err: %v want message containing: %s", tt.V, err, tt.WantMsg)
+			}
+		})
+	}
+}
+
+func TestValidateSchemaSequential(t *testing.T) {
+	type Inner struct {
+		Code string `flatfile:"1,2"`
+		Name string `flatfile:"2,4"`
+	}
+	var tests = []struct {
+		V       interface{}
+		WantMsg string
+	}{
+		{&struct {
+			Name   string `flatfile:"1,10"`
+			Amount int    `flatfile:"11,5"`
+			Filler string `flatfile:"20,2"`
+			Auto   string `flatfile:"auto,3"`
+			Notes  string
+		}{}, ""},
+		{&struct {
+			Codes  []string `flatfile:"1,2,-1"`
+			Amount int      `flatfile:"1,5"`
+		}{}, ""},
+		{&struct {
+			Name   string `flatfile:"1,10"`
+			Amount int    `flatfile:"10,5"`
+		}{}, "Field Amount starts at col 10 before field Name ends at col 10"},
+		{&struct {
+			Amount int    `flatfile:"11,5"`
+			Name   string `flatfile:"1,10"`
+		}{}, "Field Name starts at col 1 before field Amount ends at col 15"},
+		{&struct {
+			Codes []int  `flatfile:"1,2,3"`
+			Name  string `flatfile:"5,2"`
+		}{}, "Field Name starts at col 5 before field Codes ends at col 6"},
+		{&struct {
+			Inner Inner `flatfile:"1,6"`
+		}{}, "Field Inner.Name starts at col 2 before field Inner.Code ends at col 2"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestValidateSchemaSequential-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			if err := ValidateSchema(tt.V); err != nil {
+				t.Errorf("ValidateSchema(%T) without WithSequential unexpected err: %v", tt.V, err)
+			}
+			err := ValidateSchema(tt.V, WithSequential())
+			if tt.WantMsg == "" && err != nil {
+				t.Errorf("ValidateSchema(%T) unexpected err: %v", tt.V, err)
+			}
+			if tt.WantMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.WantMsg)) {
+				t.Errorf("ValidateSchema(%T) err: %v want message containing: %s", tt.V, err, tt.WantMsg)
+			}
+		})
+	}
+}
+
+func TestValidateSchemaRecordLength(t *testing.T) {
+	type Inner struct {
+		Code string `flatfile:"1,2"`
+		Name string `flatfile:"3,30"`
+	}
+	var tests = []struct {
+		V       interface{}
+		WantMsg string
+	}{
+		{&struct {
+			Name   string `flatfile:"1,10"`
+			Amount int    `flatfile:"11,5"`
+			Codes  []int  `flatfile:"16,1,5"`
+		}{}, ""},
+		{&struct {
+			Codes []string `flatfile:"1,2,-1"`
+			Items []string `flatfile:"30,2,occursAt=1:1"`
+			Inner Inner    `flatfile:"1,20"`
+		}{}, ""},
+		{&struct {
+			Name   string `flatfile:"1,10"`
+			Amount int    `flatfile:"11,11"`
+		}{}, "Field Amount ends at col 21 after the record length of 20"},
+		{&struct {
+			Name  string `flatfile:"1,10"`
+			Codes []int  `flatfile:"11,2,6"`
+		}{}, "Field Codes ends at col 22 after the record length of 20"},
+		{&struct {
+			Table [2][3]int `flatfile:"5,3"`
+		}{}, "Field Table ends at col 22 after the record length of 20"},
+		{&struct {
+			Rows [][]int `flatfile:"1,2,2x5"`
+		}{}, ""},
+		{&struct {
+			Rows [][]int `flatfile:"2,2,2x5"`
+		}{}, "Field Rows ends at col 21 after the record length of 20"},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestValidateSchemaRecordLength-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			if err := ValidateSchema(tt.V); err != nil {
+				t.Errorf("ValidateSchema(%T) without WithRecordLength unexpected err: %v", tt.V, err)
+			}
+			err := ValidateSchema(tt.V, WithRecordLength(20))
+			if tt.WantMsg == "" && err != nil {
+				t.Errorf("ValidateSchema(%T) unexpected err: %v", tt.V, err)
+			}
+			if tt.WantMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.WantMsg)) {
+				t.Errorf("ValidateSchema(%T) err: %v want message containing: %s", tt.V, err, tt.WantMsg)
+			}
+		})
+	}
+}
+
+type testNode struct {
+	Name string    `flatfile:"1,3"`
+	Next *testNode `flatfile:"4,3"`
+}
+
+type testTree struct {
+	Name   string     `flatfile:"1,3"`
+	Branch testBranch `flatfile:"4,6"`
+}
+
+type testBranch struct {
+	Code string    `flatfile:"1,3"`
+	Tree *testTree `flatfile:"4,3"`
+}
+
+func TestValidateSchemaRecursive(t *testing.T) {
+	type Inner struct {
+		Code string `flatfile:"1,2"`
+	}
+	var tests = []struct {
+		V       interface{}
+		WantMsg string
+	}{
+		{&testNode{}, "Field Next refers back to flatfile.testNode, the type of the top level struct"},
+		{&testTree{}, "Field Branch.Tree refers back to flatfile.testTree, the type of the top level struct"},
+		{&struct {
+			Node testNode `flatfile:"1,6"`
+		}{}, "Field Node.Next refers back to flatfile.testNode, the type of field Node"},
+		//the same struct type in sibling fields is not recursive
+		{&struct {
+			First  Inner  `flatfile:"1,2"`
+			Second *Inner `flatfile:"3,2"`
+		}{}, ""},
+	}
+
+	for idx, tt := range tests {
+		testName := fmt.Sprintf("TestValidateSchemaRecursive-%d", idx)
+		t.Run(testName, func(t *testing.T) {
+			err := ValidateSchema(tt.V)
+			if tt.WantMsg == "" && err != nil {
+				t.Errorf("ValidateSchema(%T) unexpected err: %v", tt.V, err)
+			}
+			if tt.WantMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.WantMsg)) {
+				t.Errorf("ValidateSchema(%T) err: %v want message containing: %s", tt.V, err, tt.WantMsg)
+			}
+		})
+	}
+}